@@ -0,0 +1,172 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Palette maps each kind of cell, as drawn by the Print methods, to a color;
+// unknown cells get the background color
+type Palette struct {
+	Background color.Color
+	Colors     map[rune]color.Color
+}
+
+// a paletted image indexes colors with a byte, the background taking index 0
+const maxColors = 255
+
+func (palette Palette) colorPalette() (color.Palette, map[rune]uint8, error) {
+	if palette.Background == nil {
+		return nil, nil, errors.New("palette has no background color")
+	}
+	if len(palette.Colors) > maxColors {
+		return nil, nil, fmt.Errorf("palette has %d colors, at most %d fit with the background", len(palette.Colors), maxColors)
+	}
+	runes := []rune{}
+	for r := range palette.Colors {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+
+	colors := color.Palette{palette.Background}
+	indexByRune := map[rune]uint8{}
+	for _, r := range runes {
+		indexByRune[r] = uint8(len(colors))
+		colors = append(colors, palette.Colors[r])
+	}
+	return colors, indexByRune, nil
+}
+
+func snapshotSize(lines []string) (width, height int) {
+	for _, line := range lines {
+		if len([]rune(line)) > width {
+			width = len([]rune(line))
+		}
+	}
+	return width, len(lines)
+}
+
+func drawFrame(lines []string, width, height int, colors color.Palette, indexByRune map[rune]uint8, scale int) *image.Paletted {
+	frame := image.NewPaletted(image.Rect(0, 0, width*scale, height*scale), colors)
+	for y, line := range lines {
+		for x, r := range []rune(line) {
+			index := indexByRune[r]
+			if index == 0 {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					frame.SetColorIndex(x*scale+dx, y*scale+dy, index)
+				}
+			}
+		}
+	}
+	return frame
+}
+
+// NewFrame draws a grid snapshot, one scale x scale square per cell
+func NewFrame(lines []string, palette Palette, scale int) (*image.Paletted, error) {
+	colors, indexByRune, err := palette.colorPalette()
+	if err != nil {
+		return nil, err
+	}
+	width, height := snapshotSize(lines)
+	return drawFrame(lines, width, height, colors, indexByRune, scale), nil
+}
+
+func WritePNG(w io.Writer, lines []string, palette Palette, scale int) error {
+	frame, err := NewFrame(lines, palette, scale)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, frame)
+}
+
+// Animation collects grid snapshots of a run
+type Animation struct {
+	Palette Palette
+	Scale   int
+	// delay between frames, in 100ths of a second
+	Delay     int
+	snapshots [][]string
+}
+
+func NewAnimation(palette Palette, scale int, delay int) *Animation {
+	return &Animation{
+		Palette:   palette,
+		Scale:     scale,
+		Delay:     delay,
+		snapshots: [][]string{},
+	}
+}
+
+func (animation *Animation) AddFrame(lines []string) {
+	animation.snapshots = append(animation.snapshots, lines)
+}
+
+func (animation *Animation) FrameCount() int {
+	return len(animation.snapshots)
+}
+
+// WriteGIF writes all snapshots as an animated GIF, padding them to the size
+// of the largest one
+func (animation *Animation) WriteGIF(w io.Writer) error {
+	colors, indexByRune, err := animation.Palette.colorPalette()
+	if err != nil {
+		return err
+	}
+	width, height := 0, 0
+	for _, lines := range animation.snapshots {
+		frameWidth, frameHeight := snapshotSize(lines)
+		if frameWidth > width {
+			width = frameWidth
+		}
+		if frameHeight > height {
+			height = frameHeight
+		}
+	}
+
+	out := &gif.GIF{
+		Config: image.Config{
+			ColorModel: colors,
+			Width:      width * animation.Scale,
+			Height:     height * animation.Scale,
+		},
+	}
+	for _, lines := range animation.snapshots {
+		out.Image = append(out.Image, drawFrame(lines, width, height, colors, indexByRune, animation.Scale))
+		out.Delay = append(out.Delay, animation.Delay)
+	}
+	return gif.EncodeAll(w, out)
+}
+
+// WriteFile writes the last snapshot to a .png file, or the whole animation
+// to any other file as a GIF
+func (animation *Animation) WriteFile(path string) error {
+	if strings.HasSuffix(path, ".png") && len(animation.snapshots) == 0 {
+		return errors.New("no frame to write")
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasSuffix(path, ".png") {
+		err = WritePNG(file, animation.snapshots[len(animation.snapshots)-1], animation.Palette, animation.Scale)
+	} else {
+		err = animation.WriteGIF(file)
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,61 @@
+package common
+
+import (
+	"bytes"
+	"image/color"
+	"image/gif"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var palette = Palette{
+	Background: color.Black,
+	Colors: map[rune]color.Color{
+		'#': color.White,
+		'o': color.RGBA{R: 0xff, A: 0xff},
+	},
+}
+
+func TestNewFrame(t *testing.T) {
+	frame, err := NewFrame([]string{"#.", ".o"}, palette, 2)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 4, frame.Bounds().Dx())
+	assert.Equal(t, 4, frame.Bounds().Dy())
+	assert.Equal(t, uint8(1), frame.ColorIndexAt(1, 1))
+	assert.Equal(t, uint8(0), frame.ColorIndexAt(2, 0))
+	assert.Equal(t, uint8(2), frame.ColorIndexAt(3, 3))
+}
+
+func TestWriteGIF(t *testing.T) {
+	animation := NewAnimation(palette, 1, 10)
+	animation.AddFrame([]string{"#"})
+	animation.AddFrame([]string{"##", ".o"})
+
+	var buffer bytes.Buffer
+	assert.Nil(t, animation.WriteGIF(&buffer))
+	decoded, err := gif.DecodeAll(&buffer)
+	assert.Nil(t, err)
+	assert.Len(t, decoded.Image, 2)
+	assert.Equal(t, 2, decoded.Config.Width)
+	assert.Equal(t, 2, decoded.Image[0].Bounds().Dx())
+}
+
+func TestInvalidPalette(t *testing.T) {
+	_, err := NewFrame([]string{"#"}, Palette{Colors: palette.Colors}, 1)
+	assert.ErrorContains(t, err, "no background")
+
+	tooMany := Palette{Background: color.Black, Colors: map[rune]color.Color{}}
+	for i := 0; i < 256; i++ {
+		tooMany.Colors[rune('a'+i)] = color.White
+	}
+	_, err = NewFrame([]string{"a"}, tooMany, 1)
+	assert.ErrorContains(t, err, "256 colors")
+	var buffer bytes.Buffer
+	assert.NotNil(t, NewAnimation(tooMany, 1, 10).WriteGIF(&buffer))
+
+	delete(tooMany.Colors, 'a')
+	_, err = NewFrame([]string{"a"}, tooMany, 1)
+	assert.Nil(t, err)
+}
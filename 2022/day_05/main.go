@@ -15,190 +15,347 @@ var inputData string
 
 func main() {
 	var part int
+	var show bool
 	flag.IntVar(&part, "part", 1, "part 1 or 2")
+	flag.BoolVar(&show, "stacks", false, "print the stacks after the rearrangement")
 	flag.Parse()
 
 	fmt.Println("Running part", part)
 
-	if part == 1 {
-		fmt.Println(part1(inputData))
-	} else {
-		fmt.Println(part2(inputData))
+	var crane Crane = CrateMover9000{}
+	if part == 2 {
+		crane = CrateMover9001{}
 	}
+	cargo, err := rearrange(inputData, crane)
+	if err != nil {
+		panic(err)
+	}
+	if show {
+		for _, line := range cargo.Render() {
+			fmt.Println(line)
+		}
+	}
+	fmt.Println(cargo.Tops())
 }
 
 func part1(data string) string {
-	stackCount, stackLines, instructions := parseInput(data)
-
-	stacks := parseStacks(stackCount, stackLines)
+	cargo, err := rearrange(data, CrateMover9000{})
+	if err != nil {
+		panic(err)
+	}
+	return cargo.Tops()
+}
 
-	applyInstructions9000(stacks, instructions)
+func part2(data string) string {
+	cargo, err := rearrange(data, CrateMover9001{})
+	if err != nil {
+		panic(err)
+	}
+	return cargo.Tops()
+}
 
-	res := ""
-	for _, stack := range stacks {
-		top, err := stack.Pop()
-		if err != nil {
-			continue
+func rearrange(data string, crane Crane) (*Cargo, error) {
+	drawing, procedure, found := strings.Cut(data, "\n\n")
+	if !found {
+		return nil, errors.New("missing rearrangement procedure")
+	}
+	cargo, err := ParseCargo(drawing, crane)
+	if err != nil {
+		return nil, err
+	}
+	moves, err := ParseMoves(procedure)
+	if err != nil {
+		return nil, err
+	}
+	for _, move := range moves {
+		if err := cargo.Apply(move); err != nil {
+			return nil, err
 		}
-		res += top
-
 	}
-
-	return res
+	return cargo, nil
 }
 
-func part2(data string) string {
-	stackCount, stackLines, instructions := parseInput(data)
+// Move takes Count crates from stack From to stack To, stacks are numbered
+// from 1 like in the drawing
+type Move struct {
+	Count int
+	From  int
+	To    int
+}
 
-	stacks := parseStacks(stackCount, stackLines)
+func (move Move) String() string {
+	return fmt.Sprintf("move %d from %d to %d", move.Count, move.From, move.To)
+}
 
-	applyInstructions9001(stacks, instructions)
+var moveRegexp = regexp.MustCompile(`^move (\d+) from (\d+) to (\d+)$`)
 
-	res := ""
-	for _, stack := range stacks {
-		top, err := stack.Pop()
+func ParseMove(line string) (Move, error) {
+	m := moveRegexp.FindStringSubmatch(line)
+	if m == nil {
+		return Move{}, fmt.Errorf("invalid move %q", line)
+	}
+	// the regexp only matches digits, but too many of them overflow
+	numbers := [3]int{}
+	for i := range numbers {
+		n, err := strconv.Atoi(m[i+1])
 		if err != nil {
+			return Move{}, fmt.Errorf("invalid move %q: %w", line, err)
+		}
+		numbers[i] = n
+	}
+	return Move{Count: numbers[0], From: numbers[1], To: numbers[2]}, nil
+}
+
+func ParseMoves(procedure string) ([]Move, error) {
+	moves := []Move{}
+	for _, line := range strings.Split(procedure, "\n") {
+		if line == "" {
 			continue
 		}
-		res += top
+		move, err := ParseMove(line)
+		if err != nil {
+			return nil, err
+		}
+		moves = append(moves, move)
 	}
+	return moves, nil
+}
 
-	return res
+// Crane moves crates between stacks
+type Crane interface {
+	// Move takes count crates from the top of a stack and puts them on another
+	Move(from, to *Stack, count int)
 }
 
-func parseInput(data string) (stackCount int, stackLines, instructions []string) {
-	stackLines = []string{}
-	stackCount = 0
+// CrateMover9000 moves one crate at a time, reversing their order
+type CrateMover9000 struct{}
 
-	lines := strings.Split(data, "\n")
-	// parse stackLines
-	for _, line := range lines {
-		if strings.HasPrefix(line, " 1") {
-			cols := strings.Split(line, "   ")
-			stackCount = len(cols)
-			break
-		}
-		stackLines = append(stackLines, line)
+func (crane CrateMover9000) Move(from, to *Stack, count int) {
+	crates := from.Take(count)
+	for i := len(crates) - 1; i >= 0; i-- {
+		to.Push(crates[i])
 	}
-	instructions = lines[len(stackLines)+2:]
+}
+
+// CrateMover9001 moves all crates at once, keeping their order
+type CrateMover9001 struct{}
 
-	return stackCount, stackLines, instructions
+func (crane CrateMover9001) Move(from, to *Stack, count int) {
+	to.Put(from.Take(count))
 }
 
-func parseStacks(stackCount int, lines []string) (stacks []*Stack) {
-	stacks = make([]*Stack, stackCount)
+// a move that was applied, with the crates it took from the top of its
+// origin stack, bottom first
+type undoEntry struct {
+	move   Move
+	crates []string
+}
+
+// Cargo is the drawing of the stacks, rearranged by a crane
+type Cargo struct {
+	labels []string
+	stacks []*Stack
+	crane  Crane
+	log    []undoEntry
+}
 
-	for i := range stacks {
-		stacks[i] = NewStack()
+// ParseCargo reads the stack drawing, labels line included. Crates are
+// assigned to the stack whose label they sit above, so crates can be any
+// width as long as they sit above a single label.
+func ParseCargo(drawing string, crane Crane) (*Cargo, error) {
+	lines := strings.Split(drawing, "\n")
+	labelLine := lines[len(lines)-1]
+
+	cargo := &Cargo{
+		labels: strings.Fields(labelLine),
+		crane:  crane,
+	}
+	if len(cargo.labels) == 0 {
+		return nil, errors.New("missing stack labels")
 	}
 
-	for lineIndex := len(lines) - 1; lineIndex >= 0; lineIndex-- {
+	// column span of each label
+	spans := [][2]int{}
+	column := 0
+	for _, label := range cargo.labels {
+		start := strings.Index(labelLine[column:], label) + column
+		spans = append(spans, [2]int{start, start + len(label)})
+		column = start + len(label)
+		cargo.stacks = append(cargo.stacks, NewStack())
+	}
+
+	for lineIndex := len(lines) - 2; lineIndex >= 0; lineIndex-- {
 		line := lines[lineIndex]
-		i := 0
-		for colIndex := 0; colIndex < stackCount; colIndex++ {
-			item := line[i : i+3]
-			if item[0] == '[' {
-				stacks[colIndex].Push(string(item[1]))
+		for column := 0; column < len(line); column++ {
+			if line[column] != '[' {
+				continue
+			}
+			end := strings.IndexByte(line[column:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed crate in %q", line)
 			}
-			i += 4
+			end += column
+			stackIndex := -1
+			for i, span := range spans {
+				if span[0] > end || span[1] <= column {
+					continue
+				}
+				if stackIndex >= 0 {
+					return nil, fmt.Errorf("crate %s is above both stacks %s and %s", line[column:end+1], cargo.labels[stackIndex], cargo.labels[i])
+				}
+				stackIndex = i
+			}
+			if stackIndex < 0 {
+				return nil, fmt.Errorf("crate %s is not above any stack", line[column:end+1])
+			}
+			cargo.stacks[stackIndex].Push(line[column+1 : end])
+			column = end
 		}
 	}
 
-	return stacks
+	return cargo, nil
 }
 
-func applyInstructions9000(stacks []*Stack, instructions []string) {
-	r := regexp.MustCompile(`move\ (?P<count>\d+)\ from\ (?P<from>\d+)\ to\ (?P<to>\d+)`)
-	for _, inst := range instructions {
-		m := r.FindStringSubmatch(inst)[1:]
-		count, err := strconv.Atoi(m[0])
-		if err != nil {
-			panic(err)
-		}
-		from, err := strconv.Atoi(m[1])
-		if err != nil {
-			panic(err)
-		}
-		from -= 1
-		to, err := strconv.Atoi(m[2])
-		if err != nil {
-			panic(err)
-		}
-		to -= 1
+// Validate tells why a move cannot be applied, if it cannot
+func (cargo *Cargo) Validate(move Move) error {
+	if move.From < 1 || move.From > len(cargo.stacks) {
+		return fmt.Errorf("%s: no stack %d", move, move.From)
+	}
+	if move.To < 1 || move.To > len(cargo.stacks) {
+		return fmt.Errorf("%s: no stack %d", move, move.To)
+	}
+	if move.Count < 1 {
+		return fmt.Errorf("%s: nothing to move", move)
+	}
+	if cargo.stacks[move.From-1].Len() < move.Count {
+		return fmt.Errorf("%s: stack %d has only %d crates", move, move.From, cargo.stacks[move.From-1].Len())
+	}
+	return nil
+}
 
-		for i := 0; i < count; i++ {
-			node, err := stacks[from].Pop()
-			if err != nil {
-				panic(err)
-			}
-			stacks[to].Push(node)
-		}
+func (cargo *Cargo) Apply(move Move) error {
+	if err := cargo.Validate(move); err != nil {
+		return err
 	}
+	from, to := cargo.stacks[move.From-1], cargo.stacks[move.To-1]
+	cargo.log = append(cargo.log, undoEntry{
+		move:   move,
+		crates: from.Peek(move.Count),
+	})
+	cargo.crane.Move(from, to, move.Count)
+	return nil
 }
 
-func applyInstructions9001(stacks []*Stack, instructions []string) {
-	for _, inst := range instructions {
-		r := regexp.MustCompile(`move\ (?P<count>\d+)\ from\ (?P<from>\d+)\ to\ (?P<to>\d+)`)
-		m := r.FindStringSubmatch(inst)[1:]
-		count, err := strconv.Atoi(m[0])
+// Undo reverts the last move applied and returns it
+func (cargo *Cargo) Undo() (Move, error) {
+	if len(cargo.log) == 0 {
+		return Move{}, errors.New("nothing to undo")
+	}
+	entry := cargo.log[len(cargo.log)-1]
+	cargo.log = cargo.log[:len(cargo.log)-1]
+	cargo.stacks[entry.move.To-1].Take(entry.move.Count)
+	cargo.stacks[entry.move.From-1].Put(entry.crates)
+	return entry.move, nil
+}
+
+// crates on top of each stack, empty stacks are skipped
+func (cargo *Cargo) Tops() string {
+	res := ""
+	for _, stack := range cargo.stacks {
+		top, err := stack.Top()
 		if err != nil {
-			panic(err)
+			continue
 		}
-		from, err := strconv.Atoi(m[1])
-		if err != nil {
-			panic(err)
+		res += top
+	}
+	return res
+}
+
+// Render draws the stacks like the puzzle input, labels line included
+func (cargo *Cargo) Render() []string {
+	// all columns as wide as the widest crate or label, like in the input
+	width := 0
+	height := 0
+	for i, stack := range cargo.stacks {
+		if len(cargo.labels[i]) > width {
+			width = len(cargo.labels[i])
 		}
-		from -= 1
-		to, err := strconv.Atoi(m[2])
-		if err != nil {
-			panic(err)
+		for _, crate := range stack.nodes {
+			if len(crate)+2 > width {
+				width = len(crate) + 2
+			}
 		}
-		to -= 1
+		if stack.Len() > height {
+			height = stack.Len()
+		}
+	}
 
-		tmp := []string{}
-		for i := 0; i < count; i++ {
-			node, err := stacks[from].Pop()
-			if err != nil {
-				panic(err)
+	center := func(s string, width int) string {
+		left := (width - len(s)) / 2
+		return strings.Repeat(" ", left) + s + strings.Repeat(" ", width-len(s)-left)
+	}
+
+	lines := []string{}
+	for level := height - 1; level >= 0; level-- {
+		cells := []string{}
+		for _, stack := range cargo.stacks {
+			if level >= stack.Len() {
+				cells = append(cells, strings.Repeat(" ", width))
+				continue
 			}
-			tmp = append(tmp, node)
-		}
-		for i := range tmp {
-			stacks[to].Push(tmp[len(tmp)-i-1])
+			cells = append(cells, center("["+stack.nodes[level]+"]", width))
 		}
+		lines = append(lines, strings.Join(cells, " "))
 	}
+	labels := []string{}
+	for _, label := range cargo.labels {
+		labels = append(labels, center(label, width))
+	}
+	return append(lines, strings.Join(labels, " "))
 }
 
 // Stack is a basic LIFO stack that resizes as needed.
 type Stack struct {
-	nodes string
+	// bottom first
+	nodes []string
 }
 
 // NewStack returns a new stack.
 func NewStack() *Stack {
-	return &Stack{}
+	return &Stack{nodes: []string{}}
+}
+
+func (s *Stack) Len() int {
+	return len(s.nodes)
 }
 
 // Push adds a node to the stack.
 func (s *Stack) Push(item string) {
-	s.nodes += item
+	s.nodes = append(s.nodes, item)
 }
 
-// Pop removes and returns a node from the stack in last to first order.
-func (s *Stack) Pop() (item string, err error) {
-	if len(s.nodes) == 0 {
-		return item, errors.New("empty stack")
-	}
-	item = string(s.nodes[len(s.nodes)-1])
-	s.nodes = s.nodes[:len(s.nodes)-1]
-	return item, nil
+// Put adds nodes to the stack, bottom first.
+func (s *Stack) Put(items []string) {
+	s.nodes = append(s.nodes, items...)
+}
+
+// Peek returns a copy of the count top nodes, bottom first.
+func (s *Stack) Peek(count int) []string {
+	return append([]string{}, s.nodes[len(s.nodes)-count:]...)
+}
+
+// Take removes and returns the count top nodes, bottom first.
+func (s *Stack) Take(count int) []string {
+	items := s.Peek(count)
+	s.nodes = s.nodes[:len(s.nodes)-count]
+	return items
 }
 
-// Peek returns a node from the stack in last to first order.
+// Top returns the last node of the stack.
 func (s *Stack) Top() (item string, err error) {
 	if len(s.nodes) == 0 {
 		return item, errors.New("empty stack")
 	}
-	item = string(s.nodes[len(s.nodes)-1])
+	item = s.nodes[len(s.nodes)-1]
 	return item, nil
 }
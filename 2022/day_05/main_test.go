@@ -1,6 +1,8 @@
 package main
 
 import (
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,3 +25,84 @@ func TestPart1(t *testing.T) {
 func TestPart2(t *testing.T) {
 	assert.Equal(t, "MCD", part2(data), "Failed testing part 2")
 }
+
+func parseExample(t *testing.T, crane Crane) (*Cargo, []Move) {
+	drawing, procedure, _ := strings.Cut(data, "\n\n")
+	cargo, err := ParseCargo(drawing, crane)
+	assert.Nil(t, err)
+	moves, err := ParseMoves(procedure)
+	assert.Nil(t, err)
+	return cargo, moves
+}
+
+func TestRender(t *testing.T) {
+	cargo, moves := parseExample(t, CrateMover9000{})
+	drawing, _, _ := strings.Cut(data, "\n\n")
+	assert.Equal(t, strings.Split(drawing, "\n"), cargo.Render())
+
+	assert.Nil(t, cargo.Apply(moves[0]))
+	assert.Equal(t, []string{
+		"[D]        ",
+		"[N] [C]    ",
+		"[Z] [M] [P]",
+		" 1   2   3 ",
+	}, cargo.Render())
+}
+
+func TestUndo(t *testing.T) {
+	for _, crane := range []Crane{CrateMover9000{}, CrateMover9001{}} {
+		cargo, moves := parseExample(t, crane)
+		initial := cargo.Render()
+		for _, move := range moves {
+			assert.Nil(t, cargo.Apply(move))
+		}
+		for i := len(moves) - 1; i >= 0; i-- {
+			move, err := cargo.Undo()
+			assert.Nil(t, err)
+			assert.Equal(t, moves[i], move)
+		}
+		assert.Equal(t, initial, cargo.Render())
+		_, err := cargo.Undo()
+		assert.NotNil(t, err)
+	}
+}
+
+func TestInvalidMoves(t *testing.T) {
+	cargo, _ := parseExample(t, CrateMover9001{})
+	assert.NotNil(t, cargo.Apply(Move{Count: 1, From: 4, To: 1}))
+	assert.NotNil(t, cargo.Apply(Move{Count: 1, From: 1, To: 0}))
+	assert.NotNil(t, cargo.Apply(Move{Count: 2, From: 3, To: 1}))
+	assert.Nil(t, cargo.Apply(Move{Count: 1, From: 3, To: 1}))
+	assert.NotNil(t, cargo.Apply(Move{Count: 1, From: 3, To: 1}))
+
+	_, err := ParseMove("move one from 1 to 2")
+	assert.NotNil(t, err)
+	_, err = ParseMove("move 99999999999999999999 from 1 to 2")
+	assert.ErrorIs(t, err, strconv.ErrRange)
+}
+
+func TestWideCrates(t *testing.T) {
+	drawing := `     [BB]      
+[AAA] [C]      
+  1    2    3  `
+	cargo, err := ParseCargo(drawing, CrateMover9001{})
+	assert.Nil(t, err)
+	assert.Nil(t, cargo.Apply(Move{Count: 2, From: 2, To: 3}))
+	assert.Equal(t, "AAABB", cargo.Tops())
+	rendered := cargo.Render()
+	assert.Equal(t, []string{
+		"            [BB] ",
+		"[AAA]        [C] ",
+		"  1     2     3  ",
+	}, rendered)
+
+	// the rendering parses back to the same stacks
+	parsed, err := ParseCargo(strings.Join(rendered, "\n"), CrateMover9001{})
+	assert.Nil(t, err)
+	assert.Equal(t, rendered, parsed.Render())
+}
+
+func TestAmbiguousCrate(t *testing.T) {
+	_, err := ParseCargo("[ABCDE]\n 1   2 ", CrateMover9001{})
+	assert.ErrorContains(t, err, "above both stacks 1 and 2")
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Detector finds markers in a datastream, runs of length distinct bytes,
+// one byte at a time
+type Detector struct {
+	length int
+	// last bytes read, as a ring buffer
+	window []byte
+	// occurrences of each byte in the window
+	counts [256]int
+	// number of byte values appearing more than once in the window
+	duplicates int
+	// bytes read since the last reset
+	read int
+}
+
+var ErrMarkerLength = errors.New("marker length must be at least 1")
+
+func NewDetector(length int) (*Detector, error) {
+	if length < 1 {
+		return nil, fmt.Errorf("%w, got %d", ErrMarkerLength, length)
+	}
+	return &Detector{
+		length: length,
+		window: make([]byte, length),
+	}, nil
+}
+
+// Push reads the next byte and tells whether it ends a marker
+func (detector *Detector) Push(b byte) bool {
+	slot := detector.read % detector.length
+	if detector.read >= detector.length {
+		old := detector.window[slot]
+		detector.counts[old]--
+		if detector.counts[old] == 1 {
+			detector.duplicates--
+		}
+	}
+	detector.window[slot] = b
+	detector.counts[b]++
+	if detector.counts[b] == 2 {
+		detector.duplicates++
+	}
+	detector.read++
+
+	return detector.read >= detector.length && detector.duplicates == 0
+}
+
+// Reset starts a new datastream
+func (detector *Detector) Reset() {
+	detector.counts = [256]int{}
+	detector.duplicates = 0
+	detector.read = 0
+}
+
+// Marker is found after Offset bytes of the datastream on line Line, both
+// counted from 1
+type Marker struct {
+	Line   int
+	Offset int
+}
+
+// streams the reader, calling found for every marker, and returns the number
+// of lines read; a trailing newline does not start a new line
+func scanMarkers(r io.Reader, length int, found func(marker Marker)) (int, error) {
+	reader := bufio.NewReader(r)
+	detector, err := NewDetector(length)
+	if err != nil {
+		return 0, err
+	}
+	line := 1
+	empty := true
+	for {
+		b, err := reader.ReadByte()
+		if errors.Is(err, io.EOF) {
+			if empty && line > 1 {
+				return line - 1, nil
+			}
+			return line, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		switch b {
+		case '\n':
+			line++
+			empty = true
+			detector.Reset()
+		case '\r':
+		default:
+			empty = false
+			if detector.Push(b) {
+				found(Marker{Line: line, Offset: detector.read})
+			}
+		}
+	}
+}
+
+// FindMarkers streams the reader and reports every marker, each line being a
+// datastream of its own
+func FindMarkers(r io.Reader, length int) ([]Marker, error) {
+	markers := []Marker{}
+	_, err := scanMarkers(r, length, func(marker Marker) {
+		markers = append(markers, marker)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return markers, nil
+}
+
+// FindFirstMarkers streams the reader and reports the offset of the first
+// marker of every line, in order, or 0 for a line without any marker
+func FindFirstMarkers(r io.Reader, length int) ([]int, error) {
+	offsets := []int{}
+	lines, err := scanMarkers(r, length, func(marker Marker) {
+		for len(offsets) < marker.Line {
+			offsets = append(offsets, 0)
+		}
+		if offsets[marker.Line-1] == 0 {
+			offsets[marker.Line-1] = marker.Offset
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	for len(offsets) < lines {
+		offsets = append(offsets, 0)
+	}
+	return offsets, nil
+}
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 //go:embed input.txt
@@ -25,38 +26,28 @@ func main() {
 }
 
 func part1(data string) string {
-	i := 4
-	for i <= len(data) {
-		frame := data[i-4 : i]
-		if countUniqueLetters(frame) == len(frame) {
-			break
-		}
-		i++
-	}
-	return strconv.Itoa(i)
+	return findFirstMarkers(data, 4)
 }
 
 func part2(data string) string {
-	i := 14
-	for i <= len(data) {
-		frame := data[i-14 : i]
-		if countUniqueLetters(frame) == len(frame) {
-			break
-		}
-		i++
-	}
-	return strconv.Itoa(i)
+	return findFirstMarkers(data, 14)
 }
 
-func countUniqueLetters(frame string) int {
-	uniqueLetters := map[rune]struct{}{}
-	res := 0
-	for _, letter := range frame {
-		if _, found := uniqueLetters[letter]; found {
+// offset of the first marker of every datastream, one per line, or "none"
+// for a datastream without any marker
+func findFirstMarkers(data string, length int) string {
+	offsets, err := FindFirstMarkers(strings.NewReader(data), length)
+	if err != nil {
+		panic(err)
+	}
+
+	ret := []string{}
+	for _, offset := range offsets {
+		if offset == 0 {
+			ret = append(ret, "none")
 			continue
 		}
-		uniqueLetters[letter] = struct{}{}
-		res++
+		ret = append(ret, strconv.Itoa(offset))
 	}
-	return res
+	return strings.Join(ret, "\n")
 }
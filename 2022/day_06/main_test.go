@@ -2,6 +2,7 @@ package main
 
 import (
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -32,3 +33,45 @@ func TestPart2(t *testing.T) {
 		assert.Equal(t, strconv.Itoa(d.expectedResult2), part2(d.data), "Failed testing part 2")
 	}
 }
+
+func TestMultiLine(t *testing.T) {
+	lines := []string{}
+	expected := []string{}
+	for _, d := range dataSlice {
+		lines = append(lines, d.data)
+		expected = append(expected, strconv.Itoa(d.expectedResult1))
+	}
+	assert.Equal(t, strings.Join(expected, "\n"), part1(strings.Join(lines, "\r\n")), "Failed testing multiple lines")
+}
+
+func TestFindMarkers(t *testing.T) {
+	markers, err := FindMarkers(strings.NewReader("abcabbcda\nxyzz"), 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []Marker{
+		{Line: 1, Offset: 3},
+		{Line: 1, Offset: 4},
+		{Line: 1, Offset: 5},
+		{Line: 1, Offset: 8},
+		{Line: 1, Offset: 9},
+		{Line: 2, Offset: 3},
+	}, markers)
+}
+
+func TestLinesWithoutMarker(t *testing.T) {
+	assert.Equal(t, "none\n4", part1("aaaa\nabcd"))
+	assert.Equal(t, "none", part1("aaaa"))
+	assert.Equal(t, "none", part1(""))
+	assert.Equal(t, "4\nnone\n4", part1("abcd\n\nabcd\n"))
+}
+
+func TestMarkerLength(t *testing.T) {
+	for _, length := range []int{0, -1} {
+		_, err := NewDetector(length)
+		assert.ErrorIs(t, err, ErrMarkerLength)
+		_, err = FindMarkers(strings.NewReader("abcd"), length)
+		assert.ErrorIs(t, err, ErrMarkerLength)
+	}
+	markers, err := FindMarkers(strings.NewReader("aab"), 1)
+	assert.Nil(t, err)
+	assert.Len(t, markers, 3)
+}
@@ -1,5 +1,11 @@
 package filesystem
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 type File struct {
 	name string
 	size int
@@ -12,13 +18,22 @@ func NewFile(name string, size int) File {
 	}
 }
 
+func (file File) Name() string {
+	return file.name
+}
+
+func (file File) Size() int {
+	return file.size
+}
+
 type Directory struct {
 	Name   string
 	Parent *Directory
 
 	Subdirectories map[string]*Directory
 
-	Files []File
+	// files by name, so that listing a directory twice doesn't count them twice
+	Files map[string]File
 
 	size int
 }
@@ -28,17 +43,31 @@ func NewDirectory(name string, parent *Directory) *Directory {
 		Name:           name,
 		Parent:         parent,
 		Subdirectories: map[string]*Directory{},
-		Files:          []File{},
+		Files:          map[string]File{},
 		size:           -1,
 	}
 }
 
-func (directory *Directory) AddSubdirectory(name string) {
-	directory.Subdirectories[name] = NewDirectory(name, directory)
+// add a subdirectory, or return the existing one with the same name
+func (directory *Directory) AddSubdirectory(name string) *Directory {
+	if subdir, found := directory.Subdirectories[name]; found {
+		return subdir
+	}
+	subdir := NewDirectory(name, directory)
+	directory.Subdirectories[name] = subdir
+	return subdir
 }
 
+// add a file, replacing the existing one with the same name
 func (directory *Directory) AddFile(file File) {
-	directory.Files = append(directory.Files, file)
+	directory.Files[file.name] = file
+	directory.resetSize()
+}
+
+func (directory *Directory) resetSize() {
+	for dir := directory; dir != nil; dir = dir.Parent {
+		dir.size = -1
+	}
 }
 
 func (directory *Directory) GetOrEvaluateSize() int {
@@ -57,3 +86,94 @@ func (directory *Directory) GetOrEvaluateSize() int {
 	directory.size = size
 	return size
 }
+
+func (directory *Directory) Root() *Directory {
+	root := directory
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	return root
+}
+
+// absolute path of the directory
+func (directory *Directory) Path() string {
+	if directory.Parent == nil {
+		return "/"
+	}
+	parentPath := directory.Parent.Path()
+	if parentPath == "/" {
+		return "/" + directory.Name
+	}
+	return parentPath + "/" + directory.Name
+}
+
+// sorted names of the subdirectories
+func (directory *Directory) SubdirectoryNames() []string {
+	names := []string{}
+	for name := range directory.Subdirectories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sorted names of the files
+func (directory *Directory) FileNames() []string {
+	names := []string{}
+	for name := range directory.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// walk an absolute or relative path from the directory, creating the missing
+// directories when asked to
+func (directory *Directory) resolve(path string, create bool) (*Directory, error) {
+	current := directory
+	if strings.HasPrefix(path, "/") {
+		current = directory.Root()
+	}
+	for _, name := range strings.Split(path, "/") {
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			if current.Parent != nil {
+				current = current.Parent
+			}
+			continue
+		}
+		subdir, found := current.Subdirectories[name]
+		if !found {
+			if !create {
+				return nil, fmt.Errorf("no such directory %s in %s", name, current.Path())
+			}
+			subdir = current.AddSubdirectory(name)
+		}
+		current = subdir
+	}
+	return current, nil
+}
+
+// directory at an absolute or relative path
+func (directory *Directory) Lookup(path string) (*Directory, error) {
+	return directory.resolve(path, false)
+}
+
+// file at an absolute or relative path
+func (directory *Directory) LookupFile(path string) (File, error) {
+	dirPath, name := "", path
+	if index := strings.LastIndex(path, "/"); index != -1 {
+		dirPath, name = path[:index+1], path[index+1:]
+	}
+	dir, err := directory.Lookup(dirPath)
+	if err != nil {
+		return File{}, err
+	}
+	file, found := dir.Files[name]
+	if !found {
+		return File{}, fmt.Errorf("no such file %s in %s", name, dir.Path())
+	}
+	return file, nil
+}
@@ -0,0 +1,73 @@
+package filesystem
+
+import "encoding/json"
+
+type jsonFile struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+}
+
+type jsonDirectory struct {
+	Name           string           `json:"name"`
+	Size           int              `json:"size"`
+	Files          []jsonFile       `json:"files"`
+	Subdirectories []*jsonDirectory `json:"directories"`
+}
+
+func (file File) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonFile{Name: file.name, Size: file.size})
+}
+
+func (file *File) UnmarshalJSON(data []byte) error {
+	var parsed jsonFile
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return err
+	}
+	*file = NewFile(parsed.Name, parsed.Size)
+	return nil
+}
+
+func (directory *Directory) toJSON() *jsonDirectory {
+	parsed := &jsonDirectory{
+		Name:           directory.Name,
+		Size:           directory.GetOrEvaluateSize(),
+		Files:          []jsonFile{},
+		Subdirectories: []*jsonDirectory{},
+	}
+	for _, name := range directory.FileNames() {
+		file := directory.Files[name]
+		parsed.Files = append(parsed.Files, jsonFile{Name: file.name, Size: file.size})
+	}
+	for _, name := range directory.SubdirectoryNames() {
+		parsed.Subdirectories = append(parsed.Subdirectories, directory.Subdirectories[name].toJSON())
+	}
+	return parsed
+}
+
+func (directory *Directory) fromJSON(parsed *jsonDirectory) {
+	directory.Name = parsed.Name
+	directory.Subdirectories = map[string]*Directory{}
+	directory.Files = map[string]File{}
+	directory.size = -1
+	for _, file := range parsed.Files {
+		directory.AddFile(NewFile(file.Name, file.Size))
+	}
+	for _, parsedSubdir := range parsed.Subdirectories {
+		directory.AddSubdirectory(parsedSubdir.Name).fromJSON(parsedSubdir)
+	}
+}
+
+// directories are marshalled with their sizes, which are recomputed when
+// unmarshalling
+func (directory *Directory) MarshalJSON() ([]byte, error) {
+	return json.Marshal(directory.toJSON())
+}
+
+func (directory *Directory) UnmarshalJSON(data []byte) error {
+	var parsed jsonDirectory
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return err
+	}
+	directory.fromJSON(&parsed)
+	return nil
+}
@@ -0,0 +1,86 @@
+package filesystem
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Shell replays a transcript of cd and ls commands to rebuild a file system
+type Shell struct {
+	Root    *Directory
+	Cwd     *Directory
+	listing bool
+}
+
+func NewShell() *Shell {
+	root := NewDirectory("/", nil)
+	return &Shell{
+		Root: root,
+		Cwd:  root,
+	}
+}
+
+func (shell *Shell) Execute(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	if fields[0] == "$" {
+		shell.listing = false
+		if len(fields) < 2 {
+			return fmt.Errorf("missing command in %q", line)
+		}
+		switch fields[1] {
+		case "cd":
+			if len(fields) < 3 {
+				return fmt.Errorf("cd expects one path, got %q", line)
+			}
+			// the path is the rest of the line, spaces included
+			_, path, _ := strings.Cut(strings.TrimSpace(line), " cd ")
+			// the transcript proves the directory exists, even if never listed
+			cwd, err := shell.Cwd.resolve(strings.TrimSpace(path), true)
+			if err != nil {
+				return err
+			}
+			shell.Cwd = cwd
+			return nil
+		case "ls":
+			shell.listing = true
+			return nil
+		default:
+			return fmt.Errorf("unknown command %q", fields[1])
+		}
+	}
+
+	if !shell.listing {
+		return fmt.Errorf("unexpected output %q outside of ls", line)
+	}
+	// names may contain spaces, only the first one separates them
+	kind, name, found := strings.Cut(strings.TrimSpace(line), " ")
+	if !found || name == "" {
+		return fmt.Errorf("invalid ls output %q", line)
+	}
+	if kind == "dir" {
+		shell.Cwd.AddSubdirectory(name)
+		return nil
+	}
+	size, err := strconv.Atoi(kind)
+	if err != nil {
+		return fmt.Errorf("invalid file size in %q", line)
+	}
+	shell.Cwd.AddFile(NewFile(name, size))
+	return nil
+}
+
+// rebuild the file system of a transcript and return its root
+func ParseTranscript(transcript string) (*Directory, error) {
+	shell := NewShell()
+	for i, line := range strings.Split(transcript, "\n") {
+		if err := shell.Execute(line); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+	return shell.Root, nil
+}
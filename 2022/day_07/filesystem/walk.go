@@ -0,0 +1,107 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// returned by a pre-order callback to skip the subdirectories of a directory
+var SkipDir = errors.New("skip this directory")
+
+type WalkFunc func(directory *Directory, depth int) error
+
+// visit the directory tree depth first, subdirectories sorted by name,
+// calling pre before and post after the subdirectories of each directory;
+// both callbacks are optional. When pre returns SkipDir, the subdirectories
+// are skipped but post is still called for the directory.
+func (directory *Directory) Walk(pre, post WalkFunc) error {
+	return directory.walk(pre, post, 0)
+}
+
+func (directory *Directory) walk(pre, post WalkFunc, depth int) error {
+	if pre != nil {
+		err := pre(directory, depth)
+		if err == SkipDir {
+			if post != nil {
+				return post(directory, depth)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, name := range directory.SubdirectoryNames() {
+		if err := directory.Subdirectories[name].walk(pre, post, depth+1); err != nil {
+			return err
+		}
+	}
+	if post != nil {
+		return post(directory, depth)
+	}
+	return nil
+}
+
+// Tree draws the directory like the tree command, with sizes
+func (directory *Directory) Tree() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s (%d)\n", directory.Name, directory.GetOrEvaluateSize()))
+	directory.writeTree(&sb, "")
+	return sb.String()
+}
+
+func (directory *Directory) writeTree(sb *strings.Builder, prefix string) {
+	names := append(directory.SubdirectoryNames(), directory.FileNames()...)
+	sort.Strings(names)
+	for i, name := range names {
+		branch, indent := "├── ", "│   "
+		if i == len(names)-1 {
+			branch, indent = "└── ", "    "
+		}
+		if subdir, found := directory.Subdirectories[name]; found {
+			sb.WriteString(fmt.Sprintf("%s%s%s/ (%d)\n", prefix, branch, name, subdir.GetOrEvaluateSize()))
+			subdir.writeTree(sb, prefix+indent)
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%s%s%s (%d)\n", prefix, branch, name, directory.Files[name].size))
+	}
+}
+
+// HumanSize formats a size like du -h, rounding up in powers of 1024
+func HumanSize(size int) string {
+	if size < 1024 {
+		return fmt.Sprint(size)
+	}
+	value := float64(size)
+	unit := 0
+	units := []string{"", "K", "M", "G", "T", "P"}
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if value < 10 {
+		return fmt.Sprintf("%.1f%s", math.Ceil(value*10)/10, units[unit])
+	}
+	return fmt.Sprintf("%.0f%s", math.Ceil(value), units[unit])
+}
+
+// DiskUsage lists all directories like du -h, biggest first
+func (directory *Directory) DiskUsage() string {
+	dirs := []*Directory{}
+	_ = directory.Walk(func(dir *Directory, depth int) error {
+		dirs = append(dirs, dir)
+		return nil
+	}, nil)
+	sort.SliceStable(dirs, func(i, j int) bool {
+		return dirs[i].GetOrEvaluateSize() > dirs[j].GetOrEvaluateSize()
+	})
+
+	var sb strings.Builder
+	for _, dir := range dirs {
+		sb.WriteString(fmt.Sprintf("%s\t%s\n", HumanSize(dir.GetOrEvaluateSize()), dir.Path()))
+	}
+	return sb.String()
+}
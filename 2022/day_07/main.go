@@ -2,13 +2,9 @@ package main
 
 import (
 	_ "embed"
-	"errors"
+	"encoding/json"
 	"flag"
 	"fmt"
-	"regexp"
-	"sort"
-	"strconv"
-	"strings"
 
 	"github.com/pducolin/advent-of-code/2022/day_07/filesystem"
 )
@@ -18,9 +14,20 @@ var inputData string
 
 func main() {
 	var part int
+	var format string
 	flag.IntVar(&part, "part", 1, "part 1 or 2")
+	flag.StringVar(&format, "report", "", "print the file system as tree, du or json")
 	flag.Parse()
 
+	if format != "" {
+		out, err := report(inputData, format)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Print(out)
+		return
+	}
+
 	fmt.Println("Running part", part)
 
 	if part == 1 {
@@ -30,45 +37,21 @@ func main() {
 	}
 }
 
-const CMD_BACK = "$ cd .."
-const CMD_LIST = "$ ls"
-const CMD_IN_REGEX = "\\$\\ cd\\ (\\w+)"
-const LS_DIR_REGEX = "dir\\ (\\w+)"
-const LS_FILE_REGEX = "(\\d+)\\ (.+)"
-
 const MAX_SIZE = 100000
 
 const TOTAL_SIZE = 70000000
 const MIN_FREE_SIZE = 30000000
 
-var (
-	dirRule, _   = regexp.Compile(LS_DIR_REGEX)
-	fileRule, _  = regexp.Compile(LS_FILE_REGEX)
-	cmdInRule, _ = regexp.Compile(CMD_IN_REGEX)
-)
-
 func part1(data string) string {
 	rootDir := parseFileSystem(data)
 
 	res := 0
-	dirToEvaluate := []*filesystem.Directory{rootDir}
-
-	for len(dirToEvaluate) > 0 {
-		currentDir := dirToEvaluate[0]
-		if len(dirToEvaluate) > 1 {
-			dirToEvaluate = dirToEvaluate[1:]
-		} else {
-			dirToEvaluate = []*filesystem.Directory{}
-		}
-
-		if currentDir.GetOrEvaluateSize() < MAX_SIZE {
-			res += currentDir.GetOrEvaluateSize()
+	_ = rootDir.Walk(func(directory *filesystem.Directory, depth int) error {
+		if directory.GetOrEvaluateSize() < MAX_SIZE {
+			res += directory.GetOrEvaluateSize()
 		}
-
-		for _, subdir := range currentDir.Subdirectories {
-			dirToEvaluate = append(dirToEvaluate, subdir)
-		}
-	}
+		return nil
+	}, nil)
 
 	return fmt.Sprint(res)
 }
@@ -76,80 +59,44 @@ func part1(data string) string {
 func part2(data string) string {
 	rootDir := parseFileSystem(data)
 
-	dirToEvaluate := []*filesystem.Directory{rootDir}
-	dirSizes := []int{}
-
 	availableFreeSize := TOTAL_SIZE - rootDir.GetOrEvaluateSize()
 	sizeToFreeUp := MIN_FREE_SIZE - availableFreeSize
 
-	for len(dirToEvaluate) > 0 {
-		currentDir := dirToEvaluate[0]
-		if len(dirToEvaluate) > 1 {
-			dirToEvaluate = dirToEvaluate[1:]
-		} else {
-			dirToEvaluate = []*filesystem.Directory{}
-		}
-
-		if currentDir.GetOrEvaluateSize() >= sizeToFreeUp {
-			dirSizes = append(dirSizes, currentDir.GetOrEvaluateSize())
+	res := rootDir.GetOrEvaluateSize()
+	_ = rootDir.Walk(func(directory *filesystem.Directory, depth int) error {
+		if directory.GetOrEvaluateSize() < sizeToFreeUp {
+			// subdirectories are even smaller
+			return filesystem.SkipDir
 		}
-
-		for _, subdir := range currentDir.Subdirectories {
-			dirToEvaluate = append(dirToEvaluate, subdir)
+		if directory.GetOrEvaluateSize() < res {
+			res = directory.GetOrEvaluateSize()
 		}
-	}
-
-	sort.Ints(dirSizes)
+		return nil
+	}, nil)
 
-	return fmt.Sprint(dirSizes[0])
+	return fmt.Sprint(res)
 }
 
-func parseFileSystem(data string) *filesystem.Directory {
-	currentDir := filesystem.NewDirectory("/", nil)
-	for _, line := range strings.Split(data, "\n") {
-		if line == CMD_BACK {
-			if currentDir.Parent == nil {
-				panic(errors.New("back from root"))
-			}
-			currentDir = currentDir.Parent
-			continue
-		}
-
-		if cmdInRule.MatchString(line) {
-			targetDirName := cmdInRule.FindStringSubmatch(line)[1]
-			currentDir = currentDir.Subdirectories[targetDirName]
-			continue
-		}
-
-		if line == CMD_LIST {
-			continue
-		}
-
-		if dirRule.MatchString(line) {
-			subdirName := dirRule.FindStringSubmatch(line)[1]
-			currentDir.AddSubdirectory(subdirName)
-			continue
-		}
-
-		if fileRule.MatchString(line) {
-			fileInfo := strings.Split(line, " ")
-			fileSize, err := strconv.Atoi(fileInfo[0])
-			if err != nil {
-				panic(err)
-			}
-			currentDir.AddFile(filesystem.NewFile(fileInfo[1], fileSize))
-			continue
-		}
+func report(data string, format string) (string, error) {
+	rootDir := parseFileSystem(data)
+	switch format {
+	case "tree":
+		return rootDir.Tree(), nil
+	case "du":
+		return rootDir.DiskUsage(), nil
+	case "json":
+		out, err := json.MarshalIndent(rootDir, "", "  ")
+		// newline terminated like the other reports
+		return string(out) + "\n", err
+	default:
+		return "", fmt.Errorf("unknown report format %q", format)
 	}
+}
 
-	// pop back
-	for {
-		if currentDir.Parent == nil {
-			break
-		}
-		currentDir = currentDir.Parent
+func parseFileSystem(data string) *filesystem.Directory {
+	root, err := filesystem.ParseTranscript(data)
+	if err != nil {
+		panic(err)
 	}
-
-	// and return root
-	return currentDir
+	return root
 }
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/pducolin/advent-of-code/2022/day_07/filesystem"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -37,3 +40,113 @@ func TestPart1(t *testing.T) {
 func TestPart2(t *testing.T) {
 	assert.Equal(t, "24933642", part2(data), "Failed testing part 2")
 }
+
+func TestParseTranscript(t *testing.T) {
+	transcript := `$ cd /
+$ cd ..
+$ ls
+dir a
+10 b
+$ ls
+dir a
+10 b
+$ cd a/e
+$ ls
+5 i
+$ cd /a
+$ ls
+20 f`
+	root, err := filesystem.ParseTranscript(transcript)
+	assert.Nil(t, err)
+	assert.Equal(t, 35, root.GetOrEvaluateSize())
+
+	dir, err := root.Lookup("/a/e")
+	assert.Nil(t, err)
+	assert.Equal(t, "/a/e", dir.Path())
+	assert.Equal(t, 5, dir.GetOrEvaluateSize())
+
+	dir, err = dir.Lookup("../../a")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"e"}, dir.SubdirectoryNames())
+
+	file, err := dir.LookupFile("e/i")
+	assert.Nil(t, err)
+	assert.Equal(t, 5, file.Size())
+
+	_, err = root.Lookup("/x")
+	assert.NotNil(t, err)
+
+	// names may contain spaces
+	root, err = filesystem.ParseTranscript("$ ls\ndir my docs\n$ cd my docs\n$ ls\n12 to do.txt")
+	assert.Nil(t, err)
+	file, err = root.LookupFile("my docs/to do.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, 12, file.Size())
+
+	_, err = filesystem.ParseTranscript("$ rm -rf /")
+	assert.ErrorContains(t, err, "unknown command")
+}
+
+func TestWalk(t *testing.T) {
+	root := parseFileSystem(data)
+	visits := []string{}
+	err := root.Walk(func(directory *filesystem.Directory, depth int) error {
+		visits = append(visits, "pre "+directory.Path())
+		return nil
+	}, func(directory *filesystem.Directory, depth int) error {
+		visits = append(visits, "post "+directory.Path())
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"pre /", "pre /a", "pre /a/e", "post /a/e", "post /a", "pre /d", "post /d", "post /"}, visits)
+
+	// skipping /a still closes it
+	visits = []string{}
+	err = root.Walk(func(directory *filesystem.Directory, depth int) error {
+		visits = append(visits, "pre "+directory.Path())
+		if directory.Path() == "/a" {
+			return filesystem.SkipDir
+		}
+		return nil
+	}, func(directory *filesystem.Directory, depth int) error {
+		visits = append(visits, "post "+directory.Path())
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"pre /", "pre /a", "post /a", "pre /d", "post /d", "post /"}, visits)
+}
+
+func TestReports(t *testing.T) {
+	root := parseFileSystem(data)
+	assert.Equal(t, `/ (48381165)
+├── a/ (94853)
+│   ├── e/ (584)
+│   │   └── i (584)
+│   ├── f (29116)
+│   ├── g (2557)
+│   └── h.lst (62596)
+├── b.txt (14848514)
+├── c.dat (8504156)
+└── d/ (24933642)
+    ├── d.ext (5626152)
+    ├── d.log (8033020)
+    ├── j (4060174)
+    └── k (7214296)
+`, root.Tree())
+	assert.Equal(t, "47M\t/\n24M\t/d\n93K\t/a\n584\t/a/e\n", root.DiskUsage())
+	assert.Equal(t, "1.5K", filesystem.HumanSize(1500))
+}
+
+func TestJSON(t *testing.T) {
+	root := parseFileSystem(data)
+	out, err := json.Marshal(root)
+	assert.Nil(t, err)
+
+	parsed := filesystem.NewDirectory("", nil)
+	err = json.Unmarshal(out, parsed)
+	assert.Nil(t, err)
+	assert.Equal(t, root.Tree(), parsed.Tree())
+	dir, err := parsed.Lookup("/a/e")
+	assert.Nil(t, err)
+	assert.Equal(t, "/a/e", dir.Path())
+}
@@ -4,8 +4,14 @@ import (
 	_ "embed"
 	"flag"
 	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"os"
 	"strconv"
 	"strings"
+
+	"github.com/pducolin/advent-of-code/2022/common"
 )
 
 //go:embed input.txt
@@ -13,9 +19,26 @@ var inputData string
 
 func main() {
 	var part int
+	var heatmap string
 	flag.IntVar(&part, "part", 1, "part 1 or 2")
+	flag.StringVar(&heatmap, "heatmap", "", "write a PNG heatmap of the scenic scores to this file")
 	flag.Parse()
 
+	if heatmap != "" {
+		file, err := os.Create(heatmap)
+		if err != nil {
+			panic(err)
+		}
+		if err := NewSurvey(parseTreeMap(inputData)).WriteHeatmap(file, 4); err != nil {
+			file.Close()
+			panic(err)
+		}
+		if err := file.Close(); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	fmt.Println("Running part", part)
 
 	if part == 1 {
@@ -57,191 +80,161 @@ func parseTreeMap(data string) (treeMap [][]int) {
 	return treeMap
 }
 
-func countVisibleTrees(treeMap [][]int) (visibileTreeCount int) {
-	visibleTrees := map[string]struct{}{}
-
-	for rowIndex := range treeMap {
-		for columnIndex := range treeMap[rowIndex] {
-
-			valueIndex := fmt.Sprintf("%d,%d", rowIndex, columnIndex)
-			// check is already visible
-			if _, found := visibleTrees[valueIndex]; found {
-				continue
-			}
-
-			if isVisible(treeMap, rowIndex, columnIndex) {
-				visibleTrees[valueIndex] = struct{}{}
-			}
-		}
-	}
-
-	return len(visibleTrees)
+// Survey holds, for every tree of the map, whether it can be seen from
+// outside the grid and its scenic score
+type Survey struct {
+	Visible [][]bool
+	Scores  [][]int
 }
 
-func isVisible(treeMap [][]int, rowIndex, columnIndex int) bool {
-	return visibleFromLeft(treeMap, rowIndex, columnIndex) || visibleFromRight(treeMap, rowIndex, columnIndex) || visibleFromTop(treeMap, rowIndex, columnIndex) || visibleFromBottom(treeMap, rowIndex, columnIndex)
-}
+// NewSurvey looks along every row and column in both directions. Walking a
+// line, a stack keeps the trees that can still block the view, from the
+// tallest down: a new tree hides the smaller ones, the tree left on top is the
+// one that blocks its view. Each tree is pushed and popped once per direction.
+func NewSurvey(treeMap [][]int) Survey {
+	totRows := len(treeMap)
+	totColumns := len(treeMap[0])
 
-func visibleFromLeft(treeMap [][]int, rowIndex, columnIndex int) bool {
-	if columnIndex == 0 {
-		return true
+	survey := Survey{
+		Visible: make([][]bool, totRows),
+		Scores:  make([][]int, totRows),
 	}
-
-	currentValue := treeMap[rowIndex][columnIndex]
-	for i := 0; i < columnIndex; i++ {
-		leftValue := treeMap[rowIndex][i]
-		if leftValue >= currentValue {
-			return false
+	for i := range treeMap {
+		survey.Visible[i] = make([]bool, totColumns)
+		survey.Scores[i] = make([]int, totColumns)
+		for j := range survey.Scores[i] {
+			survey.Scores[i][j] = 1
 		}
 	}
 
-	return true
-}
-
-func visibleFromRight(treeMap [][]int, rowIndex, columnIndex int) bool {
-	totColumns := len(treeMap[0])
-	if columnIndex == totColumns-1 {
-		return true
-	}
-
-	currentValue := treeMap[rowIndex][columnIndex]
-	for i := columnIndex + 1; i < totColumns; i++ {
-		rightValue := treeMap[rowIndex][i]
-		if rightValue >= currentValue {
-			return false
+	look := func(line []common.Point) {
+		stack := []int{}
+		for i, current := range line {
+			height := treeMap[current.Y][current.X]
+			for len(stack) > 0 {
+				top := line[stack[len(stack)-1]]
+				if treeMap[top.Y][top.X] >= height {
+					break
+				}
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) == 0 {
+				// nothing as tall before, seen from the edge and seeing it
+				survey.Visible[current.Y][current.X] = true
+				survey.Scores[current.Y][current.X] *= i
+			} else {
+				survey.Scores[current.Y][current.X] *= i - stack[len(stack)-1]
+			}
+			stack = append(stack, i)
 		}
 	}
 
-	return true
-}
-
-func visibleFromTop(treeMap [][]int, rowIndex, columnIndex int) bool {
-	if rowIndex == 0 {
-		return true
-	}
-
-	currentValue := treeMap[rowIndex][columnIndex]
-	for i := 0; i < rowIndex; i++ {
-		topValue := treeMap[i][columnIndex]
-		if topValue >= currentValue {
-			return false
+	for row := 0; row < totRows; row++ {
+		line := []common.Point{}
+		for column := 0; column < totColumns; column++ {
+			line = append(line, common.Point{X: column, Y: row})
 		}
+		look(line)
+		reverse(line)
+		look(line)
+	}
+	for column := 0; column < totColumns; column++ {
+		line := []common.Point{}
+		for row := 0; row < totRows; row++ {
+			line = append(line, common.Point{X: column, Y: row})
+		}
+		look(line)
+		reverse(line)
+		look(line)
 	}
 
-	return true
+	return survey
 }
 
-func visibleFromBottom(treeMap [][]int, rowIndex, columnIndex int) bool {
-	totRows := len(treeMap)
-
-	if rowIndex == totRows-1 {
-		return true
+func reverse(line []common.Point) {
+	for i, j := 0, len(line)-1; i < j; i, j = i+1, j-1 {
+		line[i], line[j] = line[j], line[i]
 	}
+}
 
-	currentValue := treeMap[rowIndex][columnIndex]
-	for i := rowIndex + 1; i < totRows; i++ {
-		bottomValue := treeMap[i][columnIndex]
-		if bottomValue >= currentValue {
-			return false
+func countVisibleTrees(treeMap [][]int) (visibileTreeCount int) {
+	for _, row := range NewSurvey(treeMap).Visible {
+		for _, visible := range row {
+			if visible {
+				visibileTreeCount++
+			}
 		}
 	}
-
-	return true
+	return visibileTreeCount
 }
 
 func findMaxScore(treeMap [][]int) (maxScore int) {
-	maxScore = 0
-
-	for rowIndex := range treeMap {
-		for columnIndex := range treeMap[rowIndex] {
-			score := evaluateScore(treeMap, rowIndex, columnIndex)
+	for _, row := range NewSurvey(treeMap).Scores {
+		for _, score := range row {
 			if maxScore < score {
 				maxScore = score
 			}
 		}
 	}
-
 	return maxScore
 }
 
-func evaluateScore(treeMap [][]int, rowIndex, columnIndex int) int {
-	return countVisibleToBottom(treeMap, rowIndex, columnIndex) *
-		countVisibleToTop(treeMap, rowIndex, columnIndex) *
-		countVisibleToLeft(treeMap, rowIndex, columnIndex) *
-		countVisibleToRight(treeMap, rowIndex, columnIndex)
-}
+// shades of the heatmap, each with and without the tint of visible trees
+const heatmapLevels = 100
 
-func countVisibleToLeft(treeMap [][]int, rowIndex, columnIndex int) int {
-	if columnIndex == 0 {
-		return 0
+// one cell kind per shade, visible ones right after hidden ones
+func heatmapCell(level int, visible bool) rune {
+	r := rune(0x100 + 2*level)
+	if visible {
+		r++
 	}
-
-	currentValue := treeMap[rowIndex][columnIndex]
-	res := 0
-	for i := columnIndex - 1; i >= 0; i-- {
-		res++
-		leftValue := treeMap[rowIndex][i]
-		if leftValue >= currentValue {
-			break
-		}
-	}
-
-	return res
+	return r
 }
 
-func countVisibleToRight(treeMap [][]int, rowIndex, columnIndex int) int {
-	totColumns := len(treeMap[0])
-	if columnIndex == totColumns-1 {
-		return 0
+func heatmapPalette() common.Palette {
+	palette := common.Palette{
+		Background: color.Black,
+		Colors:     map[rune]color.Color{},
 	}
-
-	currentValue := treeMap[rowIndex][columnIndex]
-	res := 0
-	for i := columnIndex + 1; i < totColumns; i++ {
-		res++
-		rightValue := treeMap[rowIndex][i]
-		if rightValue >= currentValue {
-			break
+	for level := 0; level < heatmapLevels; level++ {
+		shade := float64(level) / float64(heatmapLevels-1)
+		c := color.RGBA{
+			R: uint8(255 * shade),
+			G: uint8(220 * shade),
+			B: 0,
+			A: 0xff,
 		}
+		palette.Colors[heatmapCell(level, false)] = c
+		c.B = 0x80
+		palette.Colors[heatmapCell(level, true)] = c
 	}
-
-	return res
+	return palette
 }
 
-func countVisibleToTop(treeMap [][]int, rowIndex, columnIndex int) int {
-	if rowIndex == 0 {
-		return 0
-	}
-
-	currentValue := treeMap[rowIndex][columnIndex]
-	res := 0
-	for i := rowIndex - 1; i >= 0; i-- {
-		res++
-		topValue := treeMap[i][columnIndex]
-		if topValue >= currentValue {
-			break
+// WriteHeatmap draws scenic scores from black to yellow, on a logarithmic
+// scale so that the few best spots do not hide all the others; trees visible
+// from outside get a blue tint
+func (survey Survey) WriteHeatmap(w io.Writer, scale int) error {
+	maxScore := 0
+	for _, row := range survey.Scores {
+		for _, score := range row {
+			if maxScore < score {
+				maxScore = score
+			}
 		}
 	}
 
-	return res
-}
-
-func countVisibleToBottom(treeMap [][]int, rowIndex, columnIndex int) int {
-	totRows := len(treeMap)
-
-	if rowIndex == totRows-1 {
-		return 0
-	}
-
-	currentValue := treeMap[rowIndex][columnIndex]
-	res := 0
-	for i := rowIndex + 1; i < totRows; i++ {
-		res++
-		bottomValue := treeMap[i][columnIndex]
-		if bottomValue >= currentValue {
-			break
+	lines := []string{}
+	for row, scores := range survey.Scores {
+		line := []rune{}
+		for column, score := range scores {
+			level := 0
+			if maxScore > 0 {
+				level = int(math.Round(math.Log1p(float64(score)) / math.Log1p(float64(maxScore)) * (heatmapLevels - 1)))
+			}
+			line = append(line, heatmapCell(level, survey.Visible[row][column]))
 		}
+		lines = append(lines, string(line))
 	}
-
-	return res
+	return common.WritePNG(w, lines, heatmapPalette(), scale)
 }
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"bytes"
+	"image/color"
+	"image/png"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -19,3 +22,30 @@ func TestPart1(t *testing.T) {
 func TestPart2(t *testing.T) {
 	assert.Equal(t, "8", part2(data), "Failed testing part 2")
 }
+
+func TestSurvey(t *testing.T) {
+	survey := NewSurvey(parseTreeMap(data))
+	assert.Equal(t, [][]bool{
+		{true, true, true, true, true},
+		{true, true, true, false, true},
+		{true, true, false, true, true},
+		{true, false, true, false, true},
+		{true, true, true, true, true},
+	}, survey.Visible)
+	// middle 5 of the second row, and the 5 of the fourth row
+	assert.Equal(t, 4, survey.Scores[1][2])
+	assert.Equal(t, 8, survey.Scores[3][2])
+	assert.Equal(t, 0, survey.Scores[0][3])
+}
+
+func TestWriteHeatmap(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.Nil(t, NewSurvey(parseTreeMap(data)).WriteHeatmap(&buffer, 2))
+	img, err := png.Decode(&buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, img.Bounds().Dx())
+	// the best spot, in the middle of the fourth row, is the brightest
+	assert.Equal(t, heatmapPalette().Colors[heatmapCell(heatmapLevels-1, true)], color.RGBAModel.Convert(img.At(5, 6)))
+	// corners are visible, with a score of 0
+	assert.Equal(t, heatmapPalette().Colors[heatmapCell(0, true)], color.RGBAModel.Convert(img.At(0, 0)))
+}
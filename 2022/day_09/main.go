@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/pducolin/advent-of-code/2022/common"
 )
 
 //go:embed input.txt
@@ -25,238 +27,202 @@ func main() {
 	}
 }
 
-type Point struct {
-	x int
-	y int
-}
-
-func (point *Point) toString() string {
-	return fmt.Sprintf("%d,%d", point.x, point.y)
-}
-
-func abs(n int) int {
-	if n < 0 {
-		return -n
-	}
-	return n
+func part1(data string) string {
+	return strconv.Itoa(countVisited(data, 2))
 }
 
-func (point *Point) isTouching(otherPoint Point) bool {
-	return abs(point.x-otherPoint.x) <= 1 && abs(point.y-otherPoint.y) <= 1
+func part2(data string) string {
+	return strconv.Itoa(countVisited(data, 10))
 }
 
-func (point *Point) stepTo(direction string) {
-	if direction == "R" {
-		point.x += 1
-		return
-	}
-
-	if direction == "L" {
-		point.x -= 1
-		return
+func countVisited(data string, knots int) int {
+	motions, err := ParseMotions(data)
+	if err != nil {
+		panic(err)
 	}
 
-	if direction == "U" {
-		point.y -= 1
-		return
-	}
-
-	if direction == "D" {
-		point.y += 1
-		return
+	rope := NewRope(knots)
+	for _, motion := range motions {
+		rope.Apply(motion, nil)
 	}
+	return rope.Visited()
 }
 
-func part1(data string) string {
-	moves := strings.Split(data, "\n")
-
-	head := &Point{}
-	tail := &Point{}
-
-	visitedPositions := map[string]struct{}{}
+type Motion struct {
+	Direction string
+	Steps     int
+}
 
-	visitedPositions[tail.toString()] = struct{}{}
+func (motion Motion) String() string {
+	return fmt.Sprintf("%s %d", motion.Direction, motion.Steps)
+}
 
-	for _, move := range moves {
-		moveParts := strings.Split(move, " ")
-		direction := moveParts[0]
-		steps, err := strconv.Atoi(moveParts[1])
+func ParseMotions(data string) ([]Motion, error) {
+	motions := []Motion{}
+	for _, line := range strings.Split(data, "\n") {
+		direction, steps, found := strings.Cut(line, " ")
+		if !found {
+			return nil, fmt.Errorf("invalid motion %q", line)
+		}
+		if _, found := DELTAS[direction]; !found {
+			return nil, fmt.Errorf("invalid direction in %q", line)
+		}
+		count, err := strconv.Atoi(steps)
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("invalid steps in %q: %w", line, err)
 		}
+		motions = append(motions, Motion{Direction: direction, Steps: count})
+	}
+	return motions, nil
+}
 
-		// move
-		for i := 0; i < steps; i++ {
-			head.stepTo(direction)
-
-			if tail.isTouching(*head) {
-				continue
-			}
+// up is towards negative y, like rows on screen
+var DELTAS = map[string]common.Point{
+	"R": {X: 1, Y: 0},
+	"L": {X: -1, Y: 0},
+	"U": {X: 0, Y: -1},
+	"D": {X: 0, Y: 1},
+}
 
-			if tail.x == head.x {
-				// on the same column
-				// move diagonal
-				//    T
-				//    |
-				// ---H---
-				//    |
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
 
-				//    |
-				// ---H---
-				//    |
-				//    T
-				if tail.y > head.y {
-					tail.stepTo("U")
-				} else {
-					tail.stepTo("D")
-				}
-			} else if tail.y == head.y {
-				// on the same row
-				//    |
-				// ---H--T
-				//    |
+func Sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
 
-				//    |
-				// T--H---
-				//    |
-				if tail.x > head.x {
-					tail.stepTo("L")
-				} else {
-					tail.stepTo("R")
-				}
-			} else {
-				// move diagonal
-				//    |  T
-				// ---H---
-				//    |
+// Rope is a chain of knots, from head to tail, that all start at the origin
+type Rope struct {
+	knots   []common.Point
+	visited map[common.Point]struct{}
+}
 
-				// T  |
-				// ---H---
-				// 	  |
+func NewRope(knots int) *Rope {
+	rope := &Rope{
+		knots:   make([]common.Point, knots),
+		visited: map[common.Point]struct{}{},
+	}
+	rope.visited[rope.Tail()] = struct{}{}
+	return rope
+}
 
-				//    |
-				// ---H---
-				//    |  T
+func (rope *Rope) Head() common.Point {
+	return rope.knots[0]
+}
 
-				//    |
-				// ---H---
-				// T  |
+func (rope *Rope) Tail() common.Point {
+	return rope.knots[len(rope.knots)-1]
+}
 
-				if tail.x > head.x {
-					tail.stepTo("L")
-				} else if tail.x < head.x {
-					tail.stepTo("R")
-				}
-				if tail.y < head.y {
-					tail.stepTo("D")
-				} else if tail.y > head.y {
-					tail.stepTo("U")
-				}
-			}
+// number of positions the tail visited at least once
+func (rope *Rope) Visited() int {
+	return len(rope.visited)
+}
 
-			visitedPositions[tail.toString()] = struct{}{}
+// Step moves the head one step in the direction; each other knot that is no
+// longer touching the one before it moves one step towards it, diagonally
+// if needed
+func (rope *Rope) Step(direction string) {
+	delta := DELTAS[direction]
+	rope.knots[0].X += delta.X
+	rope.knots[0].Y += delta.Y
+
+	for i := 1; i < len(rope.knots); i++ {
+		dx := rope.knots[i-1].X - rope.knots[i].X
+		dy := rope.knots[i-1].Y - rope.knots[i].Y
+		if abs(dx) <= 1 && abs(dy) <= 1 {
+			// touching, and so are the following knots
+			break
 		}
+		rope.knots[i].X += Sign(dx)
+		rope.knots[i].Y += Sign(dy)
 	}
 
-	return strconv.Itoa(len(visitedPositions))
+	rope.visited[rope.Tail()] = struct{}{}
 }
 
-func part2(data string) string {
-	moves := strings.Split(data, "\n")
-
-	dots := make([]*Point, 10)
-
-	for i := range dots {
-		dots[i] = &Point{}
+// Apply runs all steps of a motion, calling onStep after each one if set
+func (rope *Rope) Apply(motion Motion, onStep func(rope *Rope)) {
+	for i := 0; i < motion.Steps; i++ {
+		rope.Step(motion.Direction)
+		if onStep != nil {
+			onStep(rope)
+		}
 	}
+}
 
-	visitedPositions := map[string]struct{}{}
-
-	visitedPositions[dots[9].toString()] = struct{}{}
+// label of a knot in the puzzle diagrams: H for the head, T for the tail of a
+// two knots rope, the knot index otherwise
+func (rope *Rope) label(i int) rune {
+	if i == 0 {
+		return 'H'
+	}
+	if len(rope.knots) == 2 {
+		return 'T'
+	}
+	return rune('0' + i%10)
+}
 
-	for _, move := range moves {
-		moveParts := strings.Split(move, " ")
-		direction := moveParts[0]
-		steps, err := strconv.Atoi(moveParts[1])
-		if err != nil {
-			panic(err)
+// Render draws the rope between the corners min and max like the puzzle does:
+// knots closer to the head cover the others, s marks the origin
+func (rope *Rope) Render(min, max common.Point) []string {
+	return render(min, max, func(point common.Point) rune {
+		for i, knot := range rope.knots {
+			if knot == point {
+				return rope.label(i)
+			}
 		}
+		if point == (common.Point{}) {
+			return 's'
+		}
+		return '.'
+	})
+}
 
-		// move
-		for i := 0; i < steps; i++ {
-			dots[0].stepTo(direction)
-
-			for otherDotIndex := 1; otherDotIndex < len(dots); otherDotIndex++ {
-
-				if dots[otherDotIndex].isTouching(*dots[otherDotIndex-1]) {
-					continue
-				}
-
-				if dots[otherDotIndex].x == dots[otherDotIndex-1].x {
-					// on the same column
-					// move diagonal
-					//    T
-					//    |
-					// ---H---
-					//    |
-
-					//    |
-					// ---H---
-					//    |
-					//    T
-					if dots[otherDotIndex].y > dots[otherDotIndex-1].y {
-						dots[otherDotIndex].stepTo("U")
-					} else {
-						dots[otherDotIndex].stepTo("D")
-					}
-				} else if dots[otherDotIndex].y == dots[otherDotIndex-1].y {
-					// on the same row
-					//    |
-					// ---H--T
-					//    |
-
-					//    |
-					// T--H---
-					//    |
-					if dots[otherDotIndex].x > dots[otherDotIndex-1].x {
-						dots[otherDotIndex].stepTo("L")
-					} else {
-						dots[otherDotIndex].stepTo("R")
-					}
-				} else {
-					// move diagonal
-					//    |  T
-					// ---H---
-					//    |
-
-					// T  |
-					// ---H---
-					// 	  |
-
-					//    |
-					// ---H---
-					//    |  T
-
-					//    |
-					// ---H---
-					// T  |
-
-					if dots[otherDotIndex].x > dots[otherDotIndex-1].x {
-						dots[otherDotIndex].stepTo("L")
-					} else if dots[otherDotIndex].x < dots[otherDotIndex-1].x {
-						dots[otherDotIndex].stepTo("R")
-					}
-					if dots[otherDotIndex].y < dots[otherDotIndex-1].y {
-						dots[otherDotIndex].stepTo("D")
-					} else if dots[otherDotIndex].y > dots[otherDotIndex-1].y {
-						dots[otherDotIndex].stepTo("U")
-					}
-				}
-			}
+// RenderVisited draws the positions visited by the tail as #, s marks the
+// origin
+func (rope *Rope) RenderVisited(min, max common.Point) []string {
+	return render(min, max, func(point common.Point) rune {
+		if point == (common.Point{}) {
+			return 's'
+		}
+		if _, found := rope.visited[point]; found {
+			return '#'
+		}
+		return '.'
+	})
+}
 
-			visitedPositions[dots[9].toString()] = struct{}{}
+func render(min, max common.Point, cell func(point common.Point) rune) []string {
+	lines := []string{}
+	for y := min.Y; y <= max.Y; y++ {
+		var sb strings.Builder
+		for x := min.X; x <= max.X; x++ {
+			sb.WriteRune(cell(common.Point{X: x, Y: y}))
 		}
+		lines = append(lines, sb.String())
 	}
+	return lines
+}
 
-	return strconv.Itoa(len(visitedPositions))
+// Frames renders the rope before any motion and after every step
+func Frames(motions []Motion, knots int, min, max common.Point) [][]string {
+	rope := NewRope(knots)
+	frames := [][]string{rope.Render(min, max)}
+	for _, motion := range motions {
+		rope.Apply(motion, func(rope *Rope) {
+			frames = append(frames, rope.Render(min, max))
+		})
+	}
+	return frames
 }
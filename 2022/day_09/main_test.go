@@ -3,6 +3,7 @@ package main
 import (
 	"testing"
 
+	"github.com/pducolin/advent-of-code/2022/common"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,3 +23,45 @@ func TestPart1(t *testing.T) {
 func TestPart2(t *testing.T) {
 	assert.Equal(t, "1", part2(data), "Failed testing part 2")
 }
+
+var (
+	windowMin = common.Point{X: 0, Y: -4}
+	windowMax = common.Point{X: 5, Y: 0}
+)
+
+func TestFrames(t *testing.T) {
+	motions, err := ParseMotions(data)
+	assert.Nil(t, err)
+
+	frames := Frames(motions, 2, windowMin, windowMax)
+	assert.Len(t, frames, 25)
+	assert.Equal(t, []string{"......", "......", "......", "......", "H....."}, frames[0])
+	assert.Equal(t, []string{"......", "......", "......", "......", "TH...."}, frames[1])
+	assert.Equal(t, []string{"......", "......", "......", "....H.", "s..T.."}, frames[5])
+	assert.Equal(t, []string{"..HT..", "......", "......", "......", "s....."}, frames[10])
+	assert.Equal(t, []string{"......", "....T.", ".....H", "......", "s....."}, frames[17])
+	assert.Equal(t, []string{"......", "......", ".TH...", "......", "s....."}, frames[24])
+
+	frames = Frames(motions, 10, windowMin, windowMax)
+	assert.Equal(t, []string{"......", "......", "......", "......", "4321H."}, frames[4])
+	assert.Equal(t, []string{"....H.", "....1.", "..432.", ".5....", "6....."}, frames[8])
+	assert.Equal(t, []string{"......", "...2..", "..H1..", ".5....", "6....."}, frames[20])
+	assert.Equal(t, []string{"......", "......", ".H23..", ".5....", "6....."}, frames[23])
+}
+
+func TestRenderVisited(t *testing.T) {
+	motions, err := ParseMotions(data)
+	assert.Nil(t, err)
+	rope := NewRope(2)
+	for _, motion := range motions {
+		rope.Apply(motion, nil)
+	}
+	assert.Equal(t, []string{"..##..", "...##.", ".####.", "....#.", "s###.."}, rope.RenderVisited(windowMin, windowMax))
+}
+
+func TestParseMotions(t *testing.T) {
+	_, err := ParseMotions("R 4\nX 2")
+	assert.NotNil(t, err)
+	_, err = ParseMotions("R four")
+	assert.NotNil(t, err)
+}
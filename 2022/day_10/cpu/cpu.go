@@ -0,0 +1,119 @@
+package cpu
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Registers struct {
+	X int
+}
+
+type Opcode struct {
+	Name string
+	// cycles needed to complete the instruction
+	Cycles   int
+	Operands int
+	// run once the last cycle of the instruction is over
+	Execute func(registers *Registers, operands []int)
+}
+
+type InstructionSet map[string]Opcode
+
+func DefaultInstructionSet() InstructionSet {
+	return InstructionSet{
+		"noop": {
+			Name:     "noop",
+			Cycles:   1,
+			Operands: 0,
+			Execute:  func(registers *Registers, operands []int) {},
+		},
+		"addx": {
+			Name:     "addx",
+			Cycles:   2,
+			Operands: 1,
+			Execute: func(registers *Registers, operands []int) {
+				registers.X += operands[0]
+			},
+		},
+	}
+}
+
+func (set InstructionSet) Add(opcode Opcode) {
+	set[opcode.Name] = opcode
+}
+
+type Instruction struct {
+	Opcode   Opcode
+	Operands []int
+}
+
+func (set InstructionSet) Parse(program string) ([]Instruction, error) {
+	instructions := []Instruction{}
+	for i, line := range strings.Split(strings.TrimSpace(program), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		opcode, found := set[fields[0]]
+		if !found {
+			return nil, fmt.Errorf("line %d: unknown opcode %q", i+1, fields[0])
+		}
+		if len(fields)-1 != opcode.Operands {
+			return nil, fmt.Errorf("line %d: %s expects %d operands, got %d", i+1, opcode.Name, opcode.Operands, len(fields)-1)
+		}
+		operands := []int{}
+		for _, field := range fields[1:] {
+			operand, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			operands = append(operands, operand)
+		}
+		instructions = append(instructions, Instruction{Opcode: opcode, Operands: operands})
+	}
+	return instructions, nil
+}
+
+// Observer is notified during each cycle, before the running instruction
+// updates the registers
+type Observer interface {
+	During(cycle int, registers Registers)
+}
+
+type ObserverFunc func(cycle int, registers Registers)
+
+func (f ObserverFunc) During(cycle int, registers Registers) {
+	f(cycle, registers)
+}
+
+type CPU struct {
+	Registers Registers
+	Cycle     int
+	observers []Observer
+}
+
+func NewCPU() *CPU {
+	return &CPU{
+		Registers: Registers{X: 1},
+		Cycle:     0,
+		observers: []Observer{},
+	}
+}
+
+func (cpu *CPU) Observe(observer Observer) {
+	cpu.observers = append(cpu.observers, observer)
+}
+
+func (cpu *CPU) Run(program []Instruction) {
+	for _, instruction := range program {
+		for i := 0; i < instruction.Opcode.Cycles; i++ {
+			cpu.Cycle++
+			for _, observer := range cpu.observers {
+				observer.During(cpu.Cycle, cpu.Registers)
+			}
+		}
+		instruction.Opcode.Execute(&cpu.Registers, instruction.Operands)
+	}
+}
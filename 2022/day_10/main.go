@@ -2,10 +2,15 @@ package main
 
 import (
 	_ "embed"
+	"errors"
 	"flag"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
+
+	"github.com/pducolin/advent-of-code/2022/day_10/cpu"
+	"github.com/pducolin/advent-of-code/2022/day_10/ocr"
 )
 
 //go:embed input.txt
@@ -33,88 +38,68 @@ const (
 )
 
 func part1(data string) string {
-	instructions := strings.Split(data, "\n")
-	x := 1
-	res := 0
-	currentInstructionIndex := 0
-	nextCycleToCheck := firstCycleToCheck
-	toAdd := []int{}
-
-	for cycleIndex := 1; cycleIndex <= lastCycleToCheck; cycleIndex++ {
-		if cycleIndex == nextCycleToCheck {
-			res += x * cycleIndex
-			nextCycleToCheck += cycleInterval
-			if nextCycleToCheck > lastCycleToCheck {
-				break
-			}
-		}
-
-		if len(toAdd) > 0 {
-			x += toAdd[0]
-			toAdd = []int{}
-			currentInstructionIndex++
-			continue
-		}
-
-		if instructions[currentInstructionIndex] == "noop" {
-			currentInstructionIndex++
-			continue
-		}
-
-		increment, err := strconv.Atoi(strings.Split(instructions[currentInstructionIndex], " ")[1])
-		if err != nil {
-			panic(err)
-		}
-		toAdd = append(toAdd, increment)
-	}
-
-	return strconv.Itoa(res)
+	signal := &SignalStrength{}
+	run(data, signal)
+	return strconv.Itoa(signal.Sum)
 }
 
 const lineLength = 40
 
+// letters on the screen, with ocr.UNKNOWN for glyphs the font lacks; the
+// first unknown glyph is drawn on stderr
 func part2(data string) string {
-	instructions := strings.Split(data, "\n")
-	spriteMiddleX := 1
-	res := ""
-	currentInstructionIndex := 0
-	toAdd := []int{}
-	currentXIndex := 0
-
-	for currentInstructionIndex < len(instructions) {
-		// draw pixel
-		if currentXIndex >= spriteMiddleX-1 && currentXIndex <= spriteMiddleX+1 {
-			res += "#"
-		} else {
-			res += "."
-		}
-		currentXIndex++
-		if currentXIndex == lineLength {
-			currentXIndex = 0
-			res += "\n"
-		}
-		// ##..##..##..##..##..##..##..##..##..##...
-		// ##..##..##..##..##..##..##..##..##..##..
-
-		// move sprite
-		if len(toAdd) > 0 {
-			spriteMiddleX += toAdd[0]
-			toAdd = []int{}
-			currentInstructionIndex++
-			continue
-		}
-
-		if instructions[currentInstructionIndex] == "noop" {
-			currentInstructionIndex++
-			continue
-		}
-
-		increment, err := strconv.Atoi(strings.Split(instructions[currentInstructionIndex], " ")[1])
-		if err != nil {
-			panic(err)
-		}
-		toAdd = append(toAdd, increment)
+	letters, err := ocr.Recognize(draw(data))
+	if errors.Is(err, ocr.ErrUnrecognized) {
+		fmt.Fprintln(os.Stderr, err)
+	} else if err != nil {
+		panic(err)
+	}
+	return letters
+}
+
+func draw(data string) string {
+	crt := &CRT{}
+	run(data, crt)
+	return crt.Screen.String()
+}
+
+func run(data string, observers ...cpu.Observer) {
+	program, err := cpu.DefaultInstructionSet().Parse(data)
+	if err != nil {
+		panic(err)
+	}
+	device := cpu.NewCPU()
+	for _, observer := range observers {
+		device.Observe(observer)
+	}
+	device.Run(program)
+}
+
+// SignalStrength sums cycle * X during the cycles to check
+type SignalStrength struct {
+	Sum int
+}
+
+func (signal *SignalStrength) During(cycle int, registers cpu.Registers) {
+	if cycle >= firstCycleToCheck && cycle <= lastCycleToCheck && (cycle-firstCycleToCheck)%cycleInterval == 0 {
+		signal.Sum += cycle * registers.X
 	}
+}
 
-	return res
+// CRT draws a pixel each cycle, lit when the 3 pixels wide sprite centered
+// on X covers it
+type CRT struct {
+	Screen strings.Builder
+}
+
+func (crt *CRT) During(cycle int, registers cpu.Registers) {
+	x := (cycle - 1) % lineLength
+	if x >= registers.X-1 && x <= registers.X+1 {
+		crt.Screen.WriteString("#")
+	} else {
+		crt.Screen.WriteString(".")
+	}
+	if x == lineLength-1 {
+		crt.Screen.WriteString("\n")
+	}
 }
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/pducolin/advent-of-code/2022/day_10/cpu"
+	"github.com/pducolin/advent-of-code/2022/day_10/ocr"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -157,7 +162,49 @@ func TestPart1(t *testing.T) {
 	assert.Equal(t, "13140", part1(data), "Failed testing part 1")
 }
 
+// program drawing the screen, moving the sprite every two cycles over the
+// pixels to light; the first two pixels must be lit, as X starts at 1
+func drawingProgram(screen string) string {
+	pixels := strings.ReplaceAll(screen, "\n", "")
+	instructions := []string{}
+	x := 1
+	for i := 2; i < len(pixels); i += 2 {
+		column := i % lineLength
+		next := -10
+		switch {
+		case pixels[i] == '#' && pixels[i+1] == '#':
+			next = column
+		case pixels[i] == '#':
+			next = column - 1
+		case pixels[i+1] == '#':
+			next = column + 2
+		}
+		instructions = append(instructions, "addx "+strconv.Itoa(next-x))
+		x = next
+	}
+	instructions = append(instructions, "noop", "noop")
+	return strings.Join(instructions, "\n")
+}
+
 func TestPart2(t *testing.T) {
+	screen := ""
+	for _, line := range strings.Split(`###...##..####.####.#..#.#..#..###.#...#
+#..#.#..#....#.#....#..#.#..#...#..#...#
+#..#.#......#..###..####.#..#...#...#.#.
+###..#.##..#...#....#..#.#..#...#....#..
+#.#..#..#.#....#....#..#.#..#...#....#..
+#..#..###.####.####.#..#..##...###...#..`, "\n") {
+		screen += line + strings.Repeat(".", lineLength-len(line)) + "\n"
+	}
+	program := drawingProgram(screen)
+	assert.Equal(t, screen, draw(program), "Failed drawing letters")
+	assert.Equal(t, "RGZEHUIY", part2(program), "Failed testing part 2")
+
+	// the example draws stripes, not letters
+	assert.Equal(t, "????????", part2(data), "Failed reading unknown glyphs")
+}
+
+func TestDraw(t *testing.T) {
 	expectedOutput := `##..##..##..##..##..##..##..##..##..##..
 ###...###...###...###...###...###...###.
 ####....####....####....####....####....
@@ -166,5 +213,61 @@ func TestPart2(t *testing.T) {
 #######.......#######.......#######.....
 `
 
-	assert.Equal(t, expectedOutput, part2(data), "Failed testing part 2")
+	assert.Equal(t, expectedOutput, draw(data), "Failed testing drawing")
+}
+
+func TestCustomOpcode(t *testing.T) {
+	set := cpu.DefaultInstructionSet()
+	set.Add(cpu.Opcode{
+		Name:     "mulx",
+		Cycles:   3,
+		Operands: 1,
+		Execute: func(registers *cpu.Registers, operands []int) {
+			registers.X *= operands[0]
+		},
+	})
+	program, err := set.Parse("addx 2\nmulx 5\nnoop")
+	assert.Nil(t, err)
+
+	device := cpu.NewCPU()
+	xByCycle := []int{}
+	device.Observe(cpu.ObserverFunc(func(cycle int, registers cpu.Registers) {
+		xByCycle = append(xByCycle, registers.X)
+	}))
+	device.Run(program)
+	assert.Equal(t, []int{1, 1, 3, 3, 3, 15}, xByCycle)
+	assert.Equal(t, 15, device.Registers.X)
+
+	_, err = set.Parse("jmp 2")
+	assert.NotNil(t, err)
+}
+
+func TestRecognize(t *testing.T) {
+	screen := `###...##..####.####.#..#.#..#
+#..#.#..#....#.#....#..#.#..#
+#..#.#......#..###..####.#..#
+###..#.##..#...#....#..#.#..#
+#.#..#..#.#....#....#..#.#..#
+#..#..###.####.####.#..#..##.
+`
+	letters, err := ocr.Recognize(screen)
+	assert.Nil(t, err)
+	assert.Equal(t, "RGZEHU", letters)
+
+	letters, err = ocr.Recognize(draw(data))
+	assert.ErrorIs(t, err, ocr.ErrUnrecognized)
+	assert.ErrorContains(t, err, "unrecognized glyph at column 0")
+	assert.Equal(t, "????????", letters)
+}
+
+func TestRecognizeLargeFont(t *testing.T) {
+	h := strings.Split("#....#\n#....#\n#....#\n#....#\n######\n#....#\n#....#\n#....#\n#....#\n#....#", "\n")
+	x := strings.Split("#....#\n#....#\n.#..#.\n.#..#.\n..##..\n..##..\n.#..#.\n.#..#.\n#....#\n#....#", "\n")
+	lines := []string{}
+	for i := range h {
+		lines = append(lines, h[i]+".."+x[i])
+	}
+	letters, err := ocr.Recognize(strings.Join(lines, "\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, "HX", letters)
 }
@@ -0,0 +1,150 @@
+package ocr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	LIT  = '#'
+	DARK = '.'
+	// written in place of glyphs missing from the font
+	UNKNOWN = '?'
+)
+
+var ErrUnrecognized = errors.New("unrecognized glyph")
+
+// Font of block letters, each Width x Height pixels and followed by Spacing
+// dark columns
+type Font struct {
+	Width   int
+	Height  int
+	Spacing int
+	letters map[string]rune
+}
+
+func NewFont(width, height, spacing int, glyphs map[rune]string) Font {
+	font := Font{
+		Width:   width,
+		Height:  height,
+		Spacing: spacing,
+		letters: map[string]rune{},
+	}
+	for letter, glyph := range glyphs {
+		font.letters[glyph] = letter
+	}
+	return font
+}
+
+// 4x6 font of the CRT puzzles
+var SMALL_FONT = NewFont(4, 6, 1, map[rune]string{
+	'A': ".##.\n#..#\n#..#\n####\n#..#\n#..#",
+	'B': "###.\n#..#\n###.\n#..#\n#..#\n###.",
+	'C': ".##.\n#..#\n#...\n#...\n#..#\n.##.",
+	'E': "####\n#...\n###.\n#...\n#...\n####",
+	'F': "####\n#...\n###.\n#...\n#...\n#...",
+	'G': ".##.\n#..#\n#...\n#.##\n#..#\n.###",
+	'H': "#..#\n#..#\n####\n#..#\n#..#\n#..#",
+	'I': ".###\n..#.\n..#.\n..#.\n..#.\n.###",
+	'J': "..##\n...#\n...#\n...#\n#..#\n.##.",
+	'K': "#..#\n#.#.\n##..\n#.#.\n#.#.\n#..#",
+	'L': "#...\n#...\n#...\n#...\n#...\n####",
+	'O': ".##.\n#..#\n#..#\n#..#\n#..#\n.##.",
+	'P': "###.\n#..#\n#..#\n###.\n#...\n#...",
+	'R': "###.\n#..#\n#..#\n###.\n#.#.\n#..#",
+	'S': ".###\n#...\n#...\n.##.\n...#\n###.",
+	'U': "#..#\n#..#\n#..#\n#..#\n#..#\n.##.",
+	// five pixels wide, the last column falls in the spacing
+	'Y': "#...\n#...\n.#.#\n..#.\n..#.\n..#.",
+	'Z': "####\n...#\n..#.\n.#..\n#...\n####",
+})
+
+// 6x10 font of the message in the sky puzzles
+var LARGE_FONT = NewFont(6, 10, 2, map[rune]string{
+	'A': "..##..\n.#..#.\n#....#\n#....#\n#....#\n######\n#....#\n#....#\n#....#\n#....#",
+	'B': "#####.\n#....#\n#....#\n#....#\n#####.\n#....#\n#....#\n#....#\n#....#\n#####.",
+	'C': ".####.\n#....#\n#.....\n#.....\n#.....\n#.....\n#.....\n#.....\n#....#\n.####.",
+	'E': "######\n#.....\n#.....\n#.....\n#####.\n#.....\n#.....\n#.....\n#.....\n######",
+	'F': "######\n#.....\n#.....\n#.....\n#####.\n#.....\n#.....\n#.....\n#.....\n#.....",
+	'G': ".####.\n#....#\n#.....\n#.....\n#.....\n#..###\n#....#\n#....#\n#...##\n.###.#",
+	'H': "#....#\n#....#\n#....#\n#....#\n######\n#....#\n#....#\n#....#\n#....#\n#....#",
+	'J': "...###\n....#.\n....#.\n....#.\n....#.\n....#.\n....#.\n#...#.\n#...#.\n.###..",
+	'K': "#....#\n#...#.\n#..#..\n#.#...\n##....\n##....\n#.#...\n#..#..\n#...#.\n#....#",
+	'L': "#.....\n#.....\n#.....\n#.....\n#.....\n#.....\n#.....\n#.....\n#.....\n######",
+	'N': "#....#\n##...#\n##...#\n#.#..#\n#.#..#\n#..#.#\n#..#.#\n#...##\n#...##\n#....#",
+	'P': "#####.\n#....#\n#....#\n#....#\n#####.\n#.....\n#.....\n#.....\n#.....\n#.....",
+	'R': "#####.\n#....#\n#....#\n#....#\n#####.\n#..#..\n#...#.\n#...#.\n#....#\n#....#",
+	'X': "#....#\n#....#\n.#..#.\n.#..#.\n..##..\n..##..\n.#..#.\n.#..#.\n#....#\n#....#",
+	'Z': "######\n.....#\n.....#\n....#.\n...#..\n..#...\n.#....\n#.....\n#.....\n######",
+})
+
+// read the letters of a screen drawn with the font, ignoring blank lines;
+// glyphs missing from the font are read as UNKNOWN and reported with
+// ErrUnrecognized once the whole screen is read
+func (font Font) Recognize(screen string) (string, error) {
+	lines := screenLines(screen)
+	if len(lines) != font.Height {
+		return "", fmt.Errorf("screen is %d lines high, font is %d", len(lines), font.Height)
+	}
+	width := 0
+	for _, line := range lines {
+		if len(line) > width {
+			width = len(line)
+		}
+	}
+
+	var sb strings.Builder
+	var unrecognized error
+	for column := 0; column < width; column += font.Width + font.Spacing {
+		rows := []string{}
+		blank := true
+		for _, line := range lines {
+			row := ""
+			for x := column; x < column+font.Width; x++ {
+				if x < len(line) && line[x] == LIT {
+					row += string(LIT)
+					blank = false
+				} else {
+					row += string(DARK)
+				}
+			}
+			rows = append(rows, row)
+		}
+		if blank {
+			continue
+		}
+		glyph := strings.Join(rows, "\n")
+		letter, found := font.letters[glyph]
+		if !found {
+			if unrecognized == nil {
+				unrecognized = fmt.Errorf("%w at column %d:\n%s", ErrUnrecognized, column, glyph)
+			}
+			letter = UNKNOWN
+		}
+		sb.WriteRune(letter)
+	}
+	return sb.String(), unrecognized
+}
+
+// read the letters of a screen, picking the font from its height
+func Recognize(screen string) (string, error) {
+	for _, font := range []Font{SMALL_FONT, LARGE_FONT} {
+		if len(screenLines(screen)) == font.Height {
+			return font.Recognize(screen)
+		}
+	}
+	return "", errors.New("no font matches the screen height")
+}
+
+func screenLines(screen string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(screen, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
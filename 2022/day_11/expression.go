@@ -0,0 +1,250 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expression computes the new worry level from the old one
+type Expression func(old int) (int, error)
+
+// BigExpression is the same computation over unbounded integers
+type BigExpression func(old *big.Int) (*big.Int, error)
+
+var ErrDivisionByZero = errors.New("division by zero")
+
+// node of the parsed expression: 'o' old, 'n' number, or an operator
+type expressionNode struct {
+	kind        rune
+	value       int
+	left, right *expressionNode
+}
+
+type token struct {
+	kind  rune // 'n' number, 'o' old, or the operator itself
+	value int
+	pos   int
+}
+
+func tokenize(input string) ([]token, error) {
+	tokens := []token{}
+	for i := 0; i < len(input); {
+		c := rune(input[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case strings.ContainsRune("+-*/()", c):
+			tokens = append(tokens, token{kind: c, pos: i})
+			i++
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(input) && unicode.IsDigit(rune(input[i])) {
+				i++
+			}
+			value, err := strconv.Atoi(input[start:i])
+			if err != nil {
+				return nil, fmt.Errorf("position %d: %w", start, err)
+			}
+			tokens = append(tokens, token{kind: 'n', value: value, pos: start})
+		case strings.HasPrefix(input[i:], "old"):
+			tokens = append(tokens, token{kind: 'o', pos: i})
+			i += len("old")
+		default:
+			return nil, fmt.Errorf("position %d: unexpected %q", i, c)
+		}
+	}
+	return tokens, nil
+}
+
+type expressionParser struct {
+	tokens []token
+	index  int
+}
+
+func parseExpressionTree(input string) (*expressionNode, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	parser := &expressionParser{tokens: tokens}
+	tree, err := parser.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if parser.index < len(tokens) {
+		return nil, fmt.Errorf("position %d: unexpected %q", tokens[parser.index].pos, tokens[parser.index].kind)
+	}
+	return tree, nil
+}
+
+// ParseExpression compiles an arithmetic expression over old, with + - * /,
+// parentheses and the usual precedence, into a closure
+func ParseExpression(input string) (Expression, error) {
+	tree, err := parseExpressionTree(input)
+	if err != nil {
+		return nil, err
+	}
+	return tree.compile(), nil
+}
+
+func ParseBigExpression(input string) (BigExpression, error) {
+	tree, err := parseExpressionTree(input)
+	if err != nil {
+		return nil, err
+	}
+	return tree.compileBig(), nil
+}
+
+func (parser *expressionParser) peek() rune {
+	if parser.index >= len(parser.tokens) {
+		return 0
+	}
+	return parser.tokens[parser.index].kind
+}
+
+func (parser *expressionParser) parseSum() (*expressionNode, error) {
+	left, err := parser.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for parser.peek() == '+' || parser.peek() == '-' {
+		operator := parser.peek()
+		parser.index++
+		right, err := parser.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = &expressionNode{kind: operator, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (parser *expressionParser) parseProduct() (*expressionNode, error) {
+	left, err := parser.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for parser.peek() == '*' || parser.peek() == '/' {
+		operator := parser.peek()
+		parser.index++
+		pos := len(parser.tokens)
+		if parser.index < len(parser.tokens) {
+			pos = parser.tokens[parser.index].pos
+		}
+		right, err := parser.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if operator == '/' && right.kind == 'n' && right.value == 0 {
+			return nil, fmt.Errorf("position %d: %w", pos, ErrDivisionByZero)
+		}
+		left = &expressionNode{kind: operator, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (parser *expressionParser) parseOperand() (*expressionNode, error) {
+	if parser.index >= len(parser.tokens) {
+		return nil, errors.New("unexpected end of expression")
+	}
+	current := parser.tokens[parser.index]
+	parser.index++
+	switch current.kind {
+	case 'o', 'n':
+		return &expressionNode{kind: current.kind, value: current.value}, nil
+	case '(':
+		inner, err := parser.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if parser.peek() != ')' {
+			return nil, fmt.Errorf("position %d: missing ')'", current.pos)
+		}
+		parser.index++
+		return inner, nil
+	default:
+		return nil, fmt.Errorf("position %d: unexpected %q", current.pos, current.kind)
+	}
+}
+
+func (node *expressionNode) compile() Expression {
+	switch node.kind {
+	case 'o':
+		return func(old int) (int, error) { return old, nil }
+	case 'n':
+		value := node.value
+		return func(old int) (int, error) { return value, nil }
+	}
+	left, right := node.left.compile(), node.right.compile()
+	var apply func(a, b int) (int, error)
+	switch node.kind {
+	case '+':
+		apply = func(a, b int) (int, error) { return a + b, nil }
+	case '-':
+		apply = func(a, b int) (int, error) { return a - b, nil }
+	case '*':
+		apply = func(a, b int) (int, error) { return a * b, nil }
+	default:
+		apply = func(a, b int) (int, error) {
+			// a literal zero is rejected when parsing, old can still be zero
+			if b == 0 {
+				return 0, ErrDivisionByZero
+			}
+			return a / b, nil
+		}
+	}
+	return func(old int) (int, error) {
+		a, err := left(old)
+		if err != nil {
+			return 0, err
+		}
+		b, err := right(old)
+		if err != nil {
+			return 0, err
+		}
+		return apply(a, b)
+	}
+}
+
+func (node *expressionNode) compileBig() BigExpression {
+	switch node.kind {
+	case 'o':
+		return func(old *big.Int) (*big.Int, error) { return old, nil }
+	case 'n':
+		value := big.NewInt(int64(node.value))
+		return func(old *big.Int) (*big.Int, error) { return value, nil }
+	}
+	left, right := node.left.compileBig(), node.right.compileBig()
+	var apply func(a, b *big.Int) (*big.Int, error)
+	switch node.kind {
+	case '+':
+		apply = func(a, b *big.Int) (*big.Int, error) { return new(big.Int).Add(a, b), nil }
+	case '-':
+		apply = func(a, b *big.Int) (*big.Int, error) { return new(big.Int).Sub(a, b), nil }
+	case '*':
+		apply = func(a, b *big.Int) (*big.Int, error) { return new(big.Int).Mul(a, b), nil }
+	default:
+		apply = func(a, b *big.Int) (*big.Int, error) {
+			if b.Sign() == 0 {
+				return nil, ErrDivisionByZero
+			}
+			// Quo truncates toward zero like int division
+			return new(big.Int).Quo(a, b), nil
+		}
+	}
+	return func(old *big.Int) (*big.Int, error) {
+		a, err := left(old)
+		if err != nil {
+			return nil, err
+		}
+		b, err := right(old)
+		if err != nil {
+			return nil, err
+		}
+		return apply(a, b)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	_ "embed"
 	"flag"
 	"fmt"
+	"math/big"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -14,197 +16,306 @@ var inputData string
 
 func main() {
 	var part int
+	var tracePath string
 	flag.IntVar(&part, "part", 1, "part 1 or 2")
+	flag.StringVar(&tracePath, "trace", "", "write every throw to this CSV file")
 	flag.Parse()
 
 	fmt.Println("Running part", part)
 
+	trace := &Trace{}
+	observers := []ThrowObserver{}
+	if tracePath != "" {
+		observers = append(observers, trace)
+	}
 	if part == 1 {
-		fmt.Println(part1(inputData))
+		fmt.Println(part1(inputData, observers...))
 	} else {
-		fmt.Println(part2(inputData))
+		fmt.Println(part2(inputData, observers...))
+	}
+
+	if tracePath != "" {
+		file, err := os.Create(tracePath)
+		if err != nil {
+			panic(err)
+		}
+		if err := trace.WriteCSV(file); err != nil {
+			file.Close()
+			panic(err)
+		}
+		if err := file.Close(); err != nil {
+			panic(err)
+		}
 	}
 }
 
-func part1(data string) string {
-	monkeys := []*Monkey{}
+func part1(data string, observers ...ThrowObserver) string {
+	monkeys, err := ParseMonkeys(data)
+	if err != nil {
+		panic(err)
+	}
+	simulation := NewSimulation(monkeys, 20, DivideRelief(3))
+	for _, observer := range observers {
+		simulation.Observe(observer)
+	}
+	if err := simulation.Run(); err != nil {
+		panic(err)
+	}
+	monkeyBusiness, err := simulation.MonkeyBusiness()
+	if err != nil {
+		panic(err)
+	}
+	return strconv.Itoa(monkeyBusiness)
+}
 
-	for _, monkeyBlock := range strings.Split(data, "\n\n") {
-		monkeys = append(monkeys, NewMonkey(strings.Split(monkeyBlock, "\n")))
+func part2(data string, observers ...ThrowObserver) string {
+	monkeys, err := ParseMonkeys(data)
+	if err != nil {
+		panic(err)
 	}
+	simulation := NewSimulation(monkeys, 10000, ModuloRelief(monkeys))
+	for _, observer := range observers {
+		simulation.Observe(observer)
+	}
+	if err := simulation.Run(); err != nil {
+		panic(err)
+	}
+	monkeyBusiness, err := simulation.MonkeyBusiness()
+	if err != nil {
+		panic(err)
+	}
+	return strconv.Itoa(monkeyBusiness)
+}
 
-	monkeyParsedItemsCounter := make([]int, len(monkeys))
+// Relief lowers the worry level after a monkey inspects an item
+type Relief func(worry int) int
 
-	for round := 0; round < 20; round++ {
-		for monkeyIndex, monkey := range monkeys {
-			// get all items to process
-			items := []int{}
-			items = append(items, monkey.Items...)
-			monkey.Items = []int{}
-			monkeyParsedItemsCounter[monkeyIndex] += len(items)
-			// process items
-			for _, item := range items {
-				// operation
-				item = monkey.ApplyOperation(item)
-				// divide by 3
-				item /= 3
-				// throw item
-				nextMonkeyIndex := monkey.TestNextMonkey(item)
-				monkeys[nextMonkeyIndex].Items = append(monkeys[nextMonkeyIndex].Items, item)
-			}
-		}
+func DivideRelief(by int) Relief {
+	return func(worry int) int {
+		return worry / by
 	}
+}
 
-	sort.Ints(monkeyParsedItemsCounter)
+// Chinese Remainder Theorem
+// x % 3 = 2
+// x = 2, 5, 8, 11, 14, 17, 20, [23]
+// x % 5 = 3
+// x = 3, 8, 13, 18, [23]
+// x % 7 = 2
+// x = 2, 9, 16, [23]
+// lcm = 3*5*7 = 105
+// ===> x = 23 + k*105
+// 23 = (lcm*k + 23) % 3 = (lcm*k + 23) % 5 = (lcm*k + 23) % 7
+// modulo by lcm does not change modulo by prime divisors
+func ModuloRelief(monkeys []*Monkey) Relief {
+	leastCommonMultiple := 1
+	for _, monkey := range monkeys {
+		leastCommonMultiple = lcm(leastCommonMultiple, monkey.Mod)
+	}
+	return func(worry int) int {
+		return worry % leastCommonMultiple
+	}
+}
 
-	res := monkeyParsedItemsCounter[len(monkeyParsedItemsCounter)-1] * monkeyParsedItemsCounter[len(monkeyParsedItemsCounter)-2]
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
 
-	return strconv.Itoa(res)
+func lcm(a, b int) int {
+	return a / gcd(a, b) * b
 }
 
-func part2(data string) string {
-	monkeys := []*Monkey{}
+type Simulation struct {
+	Monkeys []*Monkey
+	Rounds  int
+	Relief  Relief
+	// items inspected by each monkey
+	Inspections []int
+	observers   []ThrowObserver
+}
 
-	for _, monkeyBlock := range strings.Split(data, "\n\n") {
-		monkeys = append(monkeys, NewMonkey(strings.Split(monkeyBlock, "\n")))
-	}
+func (simulation *Simulation) Observe(observer ThrowObserver) {
+	simulation.observers = append(simulation.observers, observer)
+}
 
-	// all operations are periodical over
-	// least common multiple
-	leastCommonMultiple := 1
-	for _, monkey := range monkeys {
-		leastCommonMultiple *= monkey.Mod
+func NewSimulation(monkeys []*Monkey, rounds int, relief Relief) *Simulation {
+	return &Simulation{
+		Monkeys:     monkeys,
+		Rounds:      rounds,
+		Relief:      relief,
+		Inspections: make([]int, len(monkeys)),
 	}
+}
 
-	monkeyParsedItemsCounter := make([]int, len(monkeys))
-
-	for round := 0; round < 10000; round++ {
-		for monkeyIndex, monkey := range monkeys {
+// Run plays all rounds, stopping at the first operation that fails
+func (simulation *Simulation) Run() error {
+	for round := 0; round < simulation.Rounds; round++ {
+		for monkeyIndex, monkey := range simulation.Monkeys {
 			// get all items to process
-			items := []int{}
-			items = append(items, monkey.Items...)
+			items := monkey.Items
 			monkey.Items = []int{}
-			monkeyParsedItemsCounter[monkeyIndex] += len(items)
+			simulation.Inspections[monkeyIndex] += len(items)
 			// process items
 			for _, item := range items {
-				// operation
-				item = monkey.ApplyOperation(item)
-				// Chinese Remainder Theorem
-				// x % 3 = 2
-				// x = 2, 5, 8, 11, 14, 17, 20, [23]
-				// x % 5 = 3
-				// x = 3, 8, 13, 18, [23]
-				// x % 7 = 2
-				// x = 2, 9, 16, [23]
-				// lcm = 3*5*7 = 105
-				// ===> x = 23 + k*105
-				// 23 = (lcm*k + 23) % 3 = (lcm*k + 23) % 5 = (lcm*k + 23) % 7
-				// modulo by lcm does not change modulo by prime divisors
-				item %= leastCommonMultiple
+				before := item
+				worry, err := monkey.ApplyOperation(item)
+				if err != nil {
+					return fmt.Errorf("round %d, monkey %d, item %d: %w", round+1, monkeyIndex, item, err)
+				}
+				item = simulation.Relief(worry)
 				// throw item
 				nextMonkeyIndex := monkey.TestNextMonkey(item)
-				monkeys[nextMonkeyIndex].Items = append(monkeys[nextMonkeyIndex].Items, item)
+				simulation.Monkeys[nextMonkeyIndex].Items = append(simulation.Monkeys[nextMonkeyIndex].Items, item)
+				if len(simulation.observers) > 0 {
+					throw := Throw{
+						Round:       round + 1,
+						From:        monkeyIndex,
+						To:          nextMonkeyIndex,
+						WorryBefore: big.NewInt(int64(before)),
+						WorryAfter:  big.NewInt(int64(item)),
+					}
+					for _, observer := range simulation.observers {
+						observer.OnThrow(throw)
+					}
+				}
 			}
 		}
 	}
+	return nil
+}
 
-	sort.Ints(monkeyParsedItemsCounter)
+// product of the two highest inspection counts
+func (simulation *Simulation) MonkeyBusiness() (int, error) {
+	if len(simulation.Inspections) < 2 {
+		return 0, fmt.Errorf("monkey business needs at least 2 monkeys, got %d", len(simulation.Inspections))
+	}
+	counters := append([]int{}, simulation.Inspections...)
+	sort.Ints(counters)
+	return counters[len(counters)-1] * counters[len(counters)-2], nil
+}
 
-	res := monkeyParsedItemsCounter[len(monkeyParsedItemsCounter)-1] * monkeyParsedItemsCounter[len(monkeyParsedItemsCounter)-2]
+type Monkey struct {
+	Items          []int
+	ApplyOperation Expression
+	// same operation, for the naive simulation without relief
+	ApplyBigOperation BigExpression
+	Mod               int
+	IfTrue            int
+	IfFalse           int
+}
 
-	return strconv.Itoa(res)
+func (monkey *Monkey) TestNextMonkey(worry int) (nextMonkeyIndex int) {
+	if worry%monkey.Mod == 0 {
+		return monkey.IfTrue
+	}
+	return monkey.IfFalse
 }
 
-type Monkey struct {
-	Items              []int
-	CurrentItemIndexes []int
-	ApplyOperation     func(old int) int
-	Mod                int
-	TestNextMonkey     func(old int) (nextMonkeyIndex int)
-}
-
-func NewMonkey(rawMonkey []string) (monkey *Monkey) {
-	// Monkey x:
-	monkey = &Monkey{}
-	//starting items
-	monkey.parseItems(rawMonkey[1])
-	// operation
-	monkey.parseOperation(rawMonkey[2])
-	// test
-	monkey.parseTest(rawMonkey[3:])
-
-	return monkey
-}
-
-func (monkey *Monkey) parseItems(itemsRaw string) {
-	// Starting items: 79, 98
-	monkey.Items = []int{}
-	nums := strings.Split(strings.Split(itemsRaw, ":")[1], ",")
-	for _, numString := range nums {
-		n, err := strconv.Atoi(strings.TrimSpace(numString))
+func ParseMonkeys(data string) ([]*Monkey, error) {
+	monkeys := []*Monkey{}
+	for i, monkeyBlock := range strings.Split(strings.TrimSpace(data), "\n\n") {
+		monkey, err := ParseMonkey(monkeyBlock)
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("monkey %d: %w", i, err)
+		}
+		monkeys = append(monkeys, monkey)
+	}
+	for i, monkey := range monkeys {
+		for _, next := range []int{monkey.IfTrue, monkey.IfFalse} {
+			if next < 0 || next >= len(monkeys) {
+				return nil, fmt.Errorf("monkey %d: cannot throw to monkey %d", i, next)
+			}
 		}
-		monkey.Items = append(monkey.Items, n)
 	}
+	return monkeys, nil
 }
 
-func (monkey *Monkey) parseOperation(operationRaw string) {
-	// Operation: new = old * 19
-	operands := strings.Split(strings.Split(operationRaw, "=")[1], " ")
-	monkey.ApplyOperation = func(old int) int {
-		inputStr := []string{operands[1], operands[3]}
-		inputInt := []int{}
-		for _, variable := range inputStr {
-			if variable == "old" {
-				inputInt = append(inputInt, old)
-				continue
-			}
-			num, err := strconv.Atoi(strings.TrimSpace(variable))
-			if err != nil {
-				panic(err)
+// ParseMonkey reads the attributes of a monkey from their labels, in any order
+//
+//	Monkey 0:
+//	  Starting items: 79, 98
+//	  Operation: new = old * 19
+//	  Test: divisible by 23
+//	    If true: throw to monkey 2
+//	    If false: throw to monkey 3
+func ParseMonkey(block string) (*Monkey, error) {
+	monkey := &Monkey{Items: []int{}}
+	found := map[string]bool{}
+	for _, line := range strings.Split(block, "\n") {
+		label, value, hasColon := strings.Cut(strings.TrimSpace(line), ":")
+		if !hasColon {
+			return nil, fmt.Errorf("missing ':' in %q", line)
+		}
+		value = strings.TrimSpace(value)
+		var err error
+		switch {
+		case strings.HasPrefix(label, "Monkey"):
+		case label == "Starting items":
+			err = monkey.parseItems(value)
+		case label == "Operation":
+			err = monkey.parseOperation(value)
+		case label == "Test":
+			monkey.Mod, err = parseSuffixInt(value, "divisible by")
+			if err == nil && monkey.Mod <= 0 {
+				err = fmt.Errorf("invalid divisor %d", monkey.Mod)
 			}
-			inputInt = append(inputInt, num)
+		case label == "If true":
+			monkey.IfTrue, err = parseSuffixInt(value, "throw to monkey")
+		case label == "If false":
+			monkey.IfFalse, err = parseSuffixInt(value, "throw to monkey")
+		default:
+			err = fmt.Errorf("unknown attribute %q", label)
 		}
-
-		operator := operands[2]
-
-		if operator == "+" {
-			return inputInt[0] + inputInt[1]
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", label, err)
 		}
-		if operator == "*" {
-			return inputInt[0] * inputInt[1]
+		found[label] = true
+	}
+	for _, label := range []string{"Starting items", "Operation", "Test", "If true", "If false"} {
+		if !found[label] {
+			return nil, fmt.Errorf("missing %s", label)
 		}
-
-		panic(fmt.Errorf("Not allowed operation: %s", operator))
 	}
+	return monkey, nil
 }
 
-func (monkey *Monkey) parseTest(testRaw []string) {
-	// Test: divisible by 23
-	//   If true: throw to monkey 2
-	//   If false: throw to monkey 3
-	divisibleByStr := strings.Split(testRaw[0], "by")[1]
-	divisibleBy, err := strconv.Atoi(strings.TrimSpace(divisibleByStr))
-	if err != nil {
-		panic(err)
+func (monkey *Monkey) parseItems(value string) error {
+	// 79, 98
+	if value == "" {
+		return nil
 	}
-	nextIfTrueStr := strings.Split(testRaw[1], "monkey")[1]
-	nextIfTrue, err := strconv.Atoi(strings.TrimSpace(nextIfTrueStr))
-	if err != nil {
-		panic(err)
+	for _, numString := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(numString))
+		if err != nil {
+			return err
+		}
+		monkey.Items = append(monkey.Items, n)
 	}
-	nextIfFalseStr := strings.Split(testRaw[2], "monkey")[1]
-	nextIfFalse, err := strconv.Atoi(strings.TrimSpace(nextIfFalseStr))
+	return nil
+}
+
+func (monkey *Monkey) parseOperation(value string) error {
+	// new = old * 19
+	target, expression, hasEqual := strings.Cut(value, "=")
+	if !hasEqual || strings.TrimSpace(target) != "new" {
+		return fmt.Errorf("expected new = <expression>, got %q", value)
+	}
+	operation, err := ParseExpression(expression)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	monkey.Mod = divisibleBy
-	monkey.TestNextMonkey = func(old int) (nextMonkeyIndex int) {
-		if old%monkey.Mod == 0 {
-			return nextIfTrue
-		}
+	monkey.ApplyOperation = operation
+	monkey.ApplyBigOperation, err = ParseBigExpression(expression)
+	return err
+}
 
-		return nextIfFalse
+// parse "<prefix> <int>"
+func parseSuffixInt(value string, prefix string) (int, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return 0, fmt.Errorf("expected %q, got %q", prefix, value)
 	}
+	return strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(value, prefix)))
 }
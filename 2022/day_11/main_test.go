@@ -1,6 +1,8 @@
 package main
 
 import (
+	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -41,3 +43,127 @@ func TestPart1(t *testing.T) {
 func TestPart2(t *testing.T) {
 	assert.Equal(t, "2713310158", part2(data), "Failed testing part 2")
 }
+
+func TestParseExpression(t *testing.T) {
+	for input, expected := range map[string]int{
+		"old * 19":          190,
+		"old * old":         100,
+		"old + 6 * 2":       22,
+		"(old + 6) * 2 - 1": 31,
+		"old / 3 - old":     -7,
+	} {
+		expression, err := ParseExpression(input)
+		assert.Nil(t, err, input)
+		value, err := expression(10)
+		assert.Nil(t, err, input)
+		assert.Equal(t, expected, value, input)
+	}
+
+	_, err := ParseExpression("old ^ 2")
+	assert.ErrorContains(t, err, "position 4")
+	_, err = ParseExpression("old *")
+	assert.NotNil(t, err)
+	_, err = ParseExpression("old / (0)")
+	assert.ErrorIs(t, err, ErrDivisionByZero)
+	_, err = ParseBigExpression("old / 0")
+	assert.ErrorIs(t, err, ErrDivisionByZero)
+
+	// old is only known when running
+	expression, err := ParseExpression("6 / old")
+	assert.Nil(t, err)
+	_, err = expression(0)
+	assert.ErrorIs(t, err, ErrDivisionByZero)
+	bigExpression, err := ParseBigExpression("6 / old")
+	assert.Nil(t, err)
+	_, err = bigExpression(big.NewInt(0))
+	assert.ErrorIs(t, err, ErrDivisionByZero)
+}
+
+func TestParseMonkey(t *testing.T) {
+	monkey, err := ParseMonkey(`Monkey 7:
+  Operation: new = old * old - 1
+  If false: throw to monkey 3
+  Starting items: 1, 2
+  Test: divisible by 5
+  If true: throw to monkey 2`)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, monkey.Items)
+	worry, err := monkey.ApplyOperation(5)
+	assert.Nil(t, err)
+	assert.Equal(t, 24, worry)
+	assert.Equal(t, 2, monkey.TestNextMonkey(10))
+	assert.Equal(t, 3, monkey.TestNextMonkey(11))
+
+	_, err = ParseMonkey("Monkey 0:\n  Starting items: 1")
+	assert.ErrorContains(t, err, "missing Operation")
+}
+
+func TestSimulationRounds(t *testing.T) {
+	monkeys, err := ParseMonkeys(data)
+	assert.Nil(t, err)
+	simulation := NewSimulation(monkeys, 1, ModuloRelief(monkeys))
+	assert.Nil(t, simulation.Run())
+	assert.Equal(t, []int{2, 4, 3, 6}, simulation.Inspections)
+}
+
+func TestTrace(t *testing.T) {
+	monkeys, err := ParseMonkeys(data)
+	assert.Nil(t, err)
+	simulation := NewSimulation(monkeys, 1, DivideRelief(3))
+	trace := &Trace{}
+	simulation.Observe(trace)
+	assert.Nil(t, simulation.Run())
+
+	assert.Len(t, trace.Throws, 14)
+	assert.Equal(t, 79, int(trace.Throws[0].WorryBefore.Int64()))
+	assert.Equal(t, 500, int(trace.Throws[0].WorryAfter.Int64()))
+	assert.Equal(t, 3, trace.Throws[0].To)
+
+	histograms := trace.Histograms(len(monkeys))
+	assert.Equal(t, 2, histograms[0].Inspections)
+	assert.Equal(t, map[int]int{3: 2}, histograms[0].ThrowsTo)
+
+	var sb strings.Builder
+	assert.Nil(t, trace.WriteCSV(&sb))
+	assert.True(t, strings.HasPrefix(sb.String(), "round,from,to,worry_before,worry_after\n1,0,3,79,500\n"))
+}
+
+func TestModuloReliefMatchesBigIntegers(t *testing.T) {
+	monkeys, err := ParseMonkeys(data)
+	assert.Nil(t, err)
+	simulation := NewSimulation(monkeys, 20, ModuloRelief(monkeys))
+	trace := &Trace{}
+	simulation.Observe(trace)
+	assert.Nil(t, simulation.Run())
+
+	bigMonkeys, err := ParseMonkeys(data)
+	assert.Nil(t, err)
+	bigSimulation := NewBigSimulation(bigMonkeys, 20)
+	bigTrace := &Trace{}
+	bigSimulation.Observe(bigTrace)
+	assert.Nil(t, bigSimulation.Run())
+
+	assert.Equal(t, len(bigTrace.Throws), len(trace.Throws))
+	for i, throw := range trace.Throws {
+		bigThrow := bigTrace.Throws[i]
+		assert.Equal(t, bigThrow.To, throw.To)
+		mod := big.NewInt(96577)
+		assert.Equal(t, new(big.Int).Mod(bigThrow.WorryAfter, mod).Int64(), throw.WorryAfter.Int64())
+	}
+	assert.Equal(t, bigSimulation.Inspections, simulation.Inspections)
+}
+
+func TestSimulationErrors(t *testing.T) {
+	// throwing to itself is allowed, dividing by an old of 0 is not
+	monkeys, err := ParseMonkeys(`Monkey 0:
+  Starting items: 0
+  Operation: new = 6 / old
+  Test: divisible by 2
+    If true: throw to monkey 0
+    If false: throw to monkey 0`)
+	assert.Nil(t, err)
+	simulation := NewSimulation(monkeys, 1, DivideRelief(3))
+	assert.ErrorIs(t, simulation.Run(), ErrDivisionByZero)
+	_, err = simulation.MonkeyBusiness()
+	assert.ErrorContains(t, err, "at least 2 monkeys")
+}
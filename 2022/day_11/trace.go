@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+)
+
+// Throw of an item from a monkey to another, with its worry level before the
+// inspection and after the relief
+type Throw struct {
+	Round       int
+	From        int
+	To          int
+	WorryBefore *big.Int
+	WorryAfter  *big.Int
+}
+
+type ThrowObserver interface {
+	OnThrow(throw Throw)
+}
+
+// Trace records every throw
+type Trace struct {
+	Throws []Throw
+}
+
+func (trace *Trace) OnThrow(throw Throw) {
+	trace.Throws = append(trace.Throws, throw)
+}
+
+func (trace *Trace) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"round", "from", "to", "worry_before", "worry_after"}); err != nil {
+		return err
+	}
+	for _, throw := range trace.Throws {
+		err := writer.Write([]string{
+			strconv.Itoa(throw.Round),
+			strconv.Itoa(throw.From),
+			strconv.Itoa(throw.To),
+			throw.WorryBefore.String(),
+			throw.WorryAfter.String(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Histogram of the throws of a monkey
+type Histogram struct {
+	Monkey      int
+	Inspections int
+	// throws by receiving monkey
+	ThrowsTo map[int]int
+	// inspections by round
+	InspectionsByRound map[int]int
+}
+
+func (trace *Trace) Histograms(monkeysCount int) []Histogram {
+	histograms := []Histogram{}
+	for i := 0; i < monkeysCount; i++ {
+		histograms = append(histograms, Histogram{
+			Monkey:             i,
+			ThrowsTo:           map[int]int{},
+			InspectionsByRound: map[int]int{},
+		})
+	}
+	for _, throw := range trace.Throws {
+		histogram := &histograms[throw.From]
+		histogram.Inspections++
+		histogram.ThrowsTo[throw.To]++
+		histogram.InspectionsByRound[throw.Round]++
+	}
+	return histograms
+}
+
+// BigSimulation runs the monkeys on unbounded worry levels, with no relief,
+// to check the modular reduction against the real values
+type BigSimulation struct {
+	Monkeys     []*Monkey
+	Rounds      int
+	Inspections []int
+	observers   []ThrowObserver
+}
+
+func NewBigSimulation(monkeys []*Monkey, rounds int) *BigSimulation {
+	return &BigSimulation{
+		Monkeys:     monkeys,
+		Rounds:      rounds,
+		Inspections: make([]int, len(monkeys)),
+	}
+}
+
+func (simulation *BigSimulation) Observe(observer ThrowObserver) {
+	simulation.observers = append(simulation.observers, observer)
+}
+
+func (simulation *BigSimulation) Run() error {
+	items := make([][]*big.Int, len(simulation.Monkeys))
+	for i, monkey := range simulation.Monkeys {
+		for _, item := range monkey.Items {
+			items[i] = append(items[i], big.NewInt(int64(item)))
+		}
+	}
+	remainder := new(big.Int)
+	for round := 0; round < simulation.Rounds; round++ {
+		for monkeyIndex, monkey := range simulation.Monkeys {
+			monkeyItems := items[monkeyIndex]
+			items[monkeyIndex] = nil
+			simulation.Inspections[monkeyIndex] += len(monkeyItems)
+			mod := big.NewInt(int64(monkey.Mod))
+			for _, item := range monkeyItems {
+				worry, err := monkey.ApplyBigOperation(item)
+				if err != nil {
+					return fmt.Errorf("round %d, monkey %d, item %s: %w", round+1, monkeyIndex, item, err)
+				}
+				nextMonkeyIndex := monkey.IfFalse
+				if remainder.Rem(worry, mod).Sign() == 0 {
+					nextMonkeyIndex = monkey.IfTrue
+				}
+				items[nextMonkeyIndex] = append(items[nextMonkeyIndex], worry)
+				throw := Throw{
+					Round:       round + 1,
+					From:        monkeyIndex,
+					To:          nextMonkeyIndex,
+					WorryBefore: item,
+					WorryAfter:  worry,
+				}
+				for _, observer := range simulation.observers {
+					observer.OnThrow(throw)
+				}
+			}
+		}
+	}
+	return nil
+}
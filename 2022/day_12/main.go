@@ -4,8 +4,6 @@ import (
 	_ "embed"
 	"flag"
 	"fmt"
-	"math"
-	"sort"
 	"strconv"
 	"strings"
 
@@ -17,11 +15,34 @@ var inputData string
 
 func main() {
 	var part int
+	var render string
+	var show bool
 	flag.IntVar(&part, "part", 1, "part 1 or 2")
+	flag.StringVar(&render, "render", "", "write the route over the elevations to this PNG")
+	flag.BoolVar(&show, "route", false, "print the route")
 	flag.Parse()
 
 	fmt.Println("Running part", part)
 
+	if render != "" {
+		if err := renderPNG(inputData, render); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if show {
+		heightmap, startingPoint, targetPoint := parseMap(inputData)
+		path, err := findShortestPath(heightmap, startingPoint, targetPoint)
+		if err != nil {
+			panic(err)
+		}
+		for _, line := range RenderRoute(heightmap, path) {
+			fmt.Println(line)
+		}
+		return
+	}
+
 	if part == 1 {
 		fmt.Println(part1(inputData))
 	} else {
@@ -32,38 +53,57 @@ func main() {
 func part1(data string) string {
 	heightmap, startingPoint, targetPoint := parseMap(data)
 
-	res, err := findShortestPath(heightmap, startingPoint, targetPoint)
+	path, err := findShortestPath(heightmap, startingPoint, targetPoint)
 
 	if err != nil {
 		panic(err)
 	}
 
-	return strconv.Itoa(res)
+	return strconv.Itoa(len(path) - 1)
 }
 
 func part2(data string) string {
 	heightmap, _, targetPoint := parseMap(data)
 
-	startingPoints := []Point{}
-	for point, height := range heightmap {
-		if height == 0 {
-			startingPoints = append(startingPoints, point)
-		}
+	// walk down from the target, one traversal reaches the closest lowest point
+	path, err := findShortestPathFrom(heightmap, []Point{targetPoint}, func(point Point) bool {
+		return heightmap[point] == 0
+	}, Descending(CanClimb))
+	if err != nil {
+		panic(err)
 	}
 
-	pathDistances := []int{}
+	return strconv.Itoa(len(path) - 1)
+}
 
-	for _, start := range startingPoints {
-		distance, err := findShortestPath(heightmap, start, targetPoint)
-		if err != nil {
-			continue
-		}
-		pathDistances = append(pathDistances, distance)
+// ClimbRule tells whether one can step from a cell of height from to a
+// neighbouring cell of height to
+type ClimbRule func(from, to int) bool
+
+// at most one step higher, as high as needed lower
+func CanClimb(from, to int) bool {
+	return to-from <= 1
+}
+
+// Descending reverses a rule, to walk routes backwards from their end
+func Descending(canClimb ClimbRule) ClimbRule {
+	return func(from, to int) bool {
+		return canClimb(to, from)
 	}
+}
 
-	sort.Ints(pathDistances)
+// Elevation maps a character of the map to its height
+type Elevation func(r rune) int
 
-	return strconv.Itoa(pathDistances[0])
+// letters from a to z, starting point at a, target at z
+func LetterElevation(r rune) int {
+	switch r {
+	case 'S':
+		return 0
+	case 'E':
+		return int('z' - 'a')
+	}
+	return int(r - 'a')
 }
 
 type Point struct {
@@ -71,73 +111,68 @@ type Point struct {
 	column int
 }
 
-func (point *Point) GetNeighbours(heightmap map[Point]int) (neighbours []Point) {
+func (point *Point) GetNeighbours(heightmap map[Point]int, canClimb ClimbRule) (neighbours []Point) {
 	neighbours = []Point{}
 	currentHeight := heightmap[*point]
 
-	// up
-	neighbour := Point{row: point.row - 1, column: point.column}
-	if neighbourHeight, found := heightmap[neighbour]; found && neighbourHeight-currentHeight <= 1 {
-		neighbours = append(neighbours, neighbour)
-	}
-
-	// down
-	neighbour = Point{row: point.row + 1, column: point.column}
-	if neighbourHeight, found := heightmap[neighbour]; found && neighbourHeight-currentHeight <= 1 {
-		neighbours = append(neighbours, neighbour)
-	}
-
-	// left
-	neighbour = Point{row: point.row, column: point.column - 1}
-	if neighbourHeight, found := heightmap[neighbour]; found && neighbourHeight-currentHeight <= 1 {
-		neighbours = append(neighbours, neighbour)
-	}
-
-	// right
-	neighbour = Point{row: point.row, column: point.column + 1}
-	if neighbourHeight, found := heightmap[neighbour]; found && neighbourHeight-currentHeight <= 1 {
-		neighbours = append(neighbours, neighbour)
+	for _, neighbour := range []Point{
+		{row: point.row - 1, column: point.column}, // up
+		{row: point.row + 1, column: point.column}, // down
+		{row: point.row, column: point.column - 1}, // left
+		{row: point.row, column: point.column + 1}, // right
+	} {
+		if neighbourHeight, found := heightmap[neighbour]; found && canClimb(currentHeight, neighbourHeight) {
+			neighbours = append(neighbours, neighbour)
+		}
 	}
 
 	return neighbours
 }
 
 func parseMap(data string) (heightmap map[Point]int, startingPoint, targetPosition Point) {
+	return parseMapWith(data, LetterElevation)
+}
+
+func parseMapWith(data string, elevation Elevation) (heightmap map[Point]int, startingPoint, targetPosition Point) {
 	heightmap = map[Point]int{}
 	for rowIndex, line := range strings.Split(data, "\n") {
 		for colIndex, value := range line {
 			point := Point{row: rowIndex, column: colIndex}
+			heightmap[point] = elevation(value)
 			if value == 'S' {
-				heightmap[point] = 0
 				startingPoint = point
-				continue
 			}
-
 			if value == 'E' {
-				heightmap[point] = int('z' - 'a')
 				targetPosition = point
-				continue
 			}
-
-			heightmap[point] = int(value - 'a')
 		}
 	}
 	return heightmap, startingPoint, targetPosition
 }
 
-func findShortestPath(heightmap map[Point]int, startingPoint, target Point) (shortestPath int, err error) {
-	visited := map[Point]struct{}{}
-	distanceFromStartingPoint := map[Point]int{}
+// shortest route from starting point to target, both included
+func findShortestPath(heightmap map[Point]int, startingPoint, target Point) (path []Point, err error) {
+	return findShortestPathFrom(heightmap, []Point{startingPoint}, func(point Point) bool {
+		return point == target
+	}, CanClimb)
+}
 
-	for point := range heightmap {
-		distanceFromStartingPoint[point] = math.MaxInt
+// shortest route from any of the starting points to the first point found
+// matching isTarget, both included
+func findShortestPathFrom(heightmap map[Point]int, startingPoints []Point, isTarget func(Point) bool, canClimb ClimbRule) (path []Point, err error) {
+	visited := map[Point]struct{}{}
+	// point we came from, on the shortest route to each visited point;
+	// starting points come from themselves
+	previous := map[Point]Point{}
+
+	priorityQueue := MapPointHeap{}
+	for _, startingPoint := range startingPoints {
+		priorityQueue = append(priorityQueue, MapPoint{
+			Point:                 startingPoint,
+			From:                  startingPoint,
+			CostFromStartingPoint: 0,
+		})
 	}
-	distanceFromStartingPoint[startingPoint] = 0
-
-	priorityQueue := MapPointHeap{MapPoint{
-		Point:                 startingPoint,
-		CostFromStartingPoint: 0,
-	}}
 
 	// heap ensures items in queue are sorted by distance from starting point
 	heap.Init(&priorityQueue)
@@ -148,26 +183,47 @@ func findShortestPath(heightmap map[Point]int, startingPoint, target Point) (sho
 		if _, found := visited[currentMapPoint.Point]; found {
 			continue
 		}
+		previous[currentMapPoint.Point] = currentMapPoint.From
 
-		if currentMapPoint.Point == target {
+		if isTarget(currentMapPoint.Point) {
 			// we are done, this is the shortest path to target
-			return currentMapPoint.CostFromStartingPoint, nil
+			return buildPath(previous, currentMapPoint.Point), nil
 		}
 
-		for _, neighbour := range currentMapPoint.Point.GetNeighbours(heightmap) {
-			heap.Push(&priorityQueue, MapPoint{Point: neighbour, CostFromStartingPoint: currentMapPoint.CostFromStartingPoint + 1})
+		for _, neighbour := range currentMapPoint.Point.GetNeighbours(heightmap, canClimb) {
+			heap.Push(&priorityQueue, MapPoint{
+				Point:                 neighbour,
+				From:                  currentMapPoint.Point,
+				CostFromStartingPoint: currentMapPoint.CostFromStartingPoint + 1,
+			})
 		}
 
 		visited[currentMapPoint.Point] = struct{}{}
 	}
 
-	return -1, fmt.Errorf("no path from %#v to %#v", startingPoint, target)
+	return nil, fmt.Errorf("no path from %#v", startingPoints)
+}
+
+// walk the predecessors back from target to the starting point it was
+// reached from
+func buildPath(previous map[Point]Point, target Point) []Point {
+	path := []Point{target}
+	for point := target; previous[point] != point; {
+		point = previous[point]
+		path = append(path, point)
+	}
+	// reverse
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
 }
 
 // Source https://pkg.go.dev/container/heap
 
 type MapPoint struct {
 	Point                 Point
+	From                  Point
 	CostFromStartingPoint int
 }
 
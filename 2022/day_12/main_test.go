@@ -19,3 +19,72 @@ func TestPart1(t *testing.T) {
 func TestPart2(t *testing.T) {
 	assert.Equal(t, "29", part2(data), "Failed testing part 2")
 }
+
+func TestRenderRoute(t *testing.T) {
+	heightmap, startingPoint, targetPoint := parseMap(data)
+	path, err := findShortestPath(heightmap, startingPoint, targetPoint)
+	assert.Nil(t, err)
+	assert.Len(t, path, 32)
+	assert.Equal(t, startingPoint, path[0])
+	assert.Equal(t, targetPoint, path[31])
+	// every step respects the climbing rule
+	for i := 0; i+1 < len(path); i++ {
+		assert.LessOrEqual(t, heightmap[path[i+1]]-heightmap[path[i]], 1)
+	}
+	lines := RenderRoute(heightmap, path)
+	assert.Len(t, lines, 5)
+	assert.Equal(t, '.', rune(lines[4][0]))
+	assert.Equal(t, 'E', rune(lines[2][5]))
+}
+
+func TestMultiSource(t *testing.T) {
+	heightmap, _, targetPoint := parseMap(data)
+	lowest := []Point{}
+	for point, height := range heightmap {
+		if height == 0 {
+			lowest = append(lowest, point)
+		}
+	}
+	// climbing from all the lowest points at once matches walking down from E
+	path, err := findShortestPathFrom(heightmap, lowest, func(point Point) bool {
+		return point == targetPoint
+	}, CanClimb)
+	assert.Nil(t, err)
+	assert.Len(t, path, 30)
+	assert.Equal(t, 0, heightmap[path[0]])
+}
+
+func TestCustomRules(t *testing.T) {
+	// digits as elevations, climbing up to two steps at once
+	heightmap, startingPoint, targetPoint := parseMapWith("S13\n975\n8E9", func(r rune) int {
+		switch r {
+		case 'S':
+			return 0
+		case 'E':
+			return 9
+		}
+		return int(r - '0')
+	})
+	_, err := findShortestPath(heightmap, startingPoint, targetPoint)
+	assert.NotNil(t, err)
+
+	path, err := findShortestPathFrom(heightmap, []Point{startingPoint}, func(point Point) bool {
+		return point == targetPoint
+	}, func(from, to int) bool {
+		return to-from <= 2
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []Point{{0, 0}, {0, 1}, {0, 2}, {1, 2}, {1, 1}, {2, 1}}, path)
+}
+
+func TestRenderElevation(t *testing.T) {
+	// the route goes right along the top row, the 'v' below stays a letter
+	heightmap, _, _ := parseMap("Sbc\nvvv")
+	path := []Point{{row: 0, column: 0}, {row: 0, column: 1}, {row: 0, column: 2}}
+	lines := RenderElevation(heightmap, path)
+	assert.Equal(t, []string{"→→E", "vvv"}, lines)
+
+	palette := elevationPalette()
+	assert.NotEqual(t, palette.Colors['→'], palette.Colors['v'])
+	assert.Equal(t, palette.Colors['→'], palette.Colors['E'])
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+
+	"github.com/pducolin/advent-of-code/2022/common"
+)
+
+func mapSize(heightmap map[Point]int) (rows, columns int) {
+	for point := range heightmap {
+		if point.row+1 > rows {
+			rows = point.row + 1
+		}
+		if point.column+1 > columns {
+			columns = point.column + 1
+		}
+	}
+	return rows, columns
+}
+
+// glyphs for a step up, down, left and right
+type arrows [4]rune
+
+// the puzzle's notation
+var asciiArrows = arrows{'^', 'v', '<', '>'}
+
+// outside a-z, so they never collide with elevation letters such as 'v'
+var unicodeArrows = arrows{'↑', '↓', '←', '→'}
+
+func (glyphs arrows) step(from, to Point) rune {
+	switch {
+	case to.row < from.row:
+		return glyphs[0]
+	case to.row > from.row:
+		return glyphs[1]
+	case to.column < from.column:
+		return glyphs[2]
+	default:
+		return glyphs[3]
+	}
+}
+
+// draw each step of the route with an arrow towards the next one, like the
+// puzzle does; other cells are drawn with background
+func drawRoute(heightmap map[Point]int, path []Point, glyphs arrows, background func(point Point) rune) []string {
+	rows, columns := mapSize(heightmap)
+	steps := map[Point]rune{}
+	for i := 0; i+1 < len(path); i++ {
+		steps[path[i]] = glyphs.step(path[i], path[i+1])
+	}
+	if len(path) > 0 {
+		steps[path[len(path)-1]] = 'E'
+	}
+
+	lines := []string{}
+	for row := 0; row < rows; row++ {
+		line := ""
+		for column := 0; column < columns; column++ {
+			point := Point{row: row, column: column}
+			if r, found := steps[point]; found {
+				line += string(r)
+				continue
+			}
+			line += string(background(point))
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// RenderRoute draws the route over an empty map, in the puzzle's notation
+func RenderRoute(heightmap map[Point]int, path []Point) []string {
+	return drawRoute(heightmap, path, asciiArrows, func(point Point) rune {
+		return '.'
+	})
+}
+
+// RenderElevation draws the route over the elevation letters, with arrows
+// that cannot be mistaken for a letter
+func RenderElevation(heightmap map[Point]int, path []Point) []string {
+	return drawRoute(heightmap, path, unicodeArrows, func(point Point) rune {
+		return rune('a' + heightmap[point])
+	})
+}
+
+// elevations from dark green to white, route in red
+func elevationPalette() common.Palette {
+	palette := common.Palette{
+		Background: color.Black,
+		Colors:     map[rune]color.Color{},
+	}
+	for height := 0; height <= int('z'-'a'); height++ {
+		level := height * 0xff / int('z'-'a')
+		palette.Colors[rune('a'+height)] = color.RGBA{
+			R: uint8(level),
+			G: uint8(0x60 + level*(0xff-0x60)/0xff),
+			B: uint8(level),
+			A: 0xff,
+		}
+	}
+	for _, r := range string(unicodeArrows[:]) + "E" {
+		palette.Colors[r] = color.RGBA{R: 0xe7, G: 0x4c, B: 0x3c, A: 0xff}
+	}
+	return palette
+}
+
+func renderPNG(data string, path string) error {
+	heightmap, startingPoint, targetPoint := parseMap(data)
+	route, err := findShortestPath(heightmap, startingPoint, targetPoint)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := common.WritePNG(file, RenderElevation(heightmap, route), elevationPalette(), 6); err != nil {
+		file.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return file.Close()
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	_ "embed"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"sort"
@@ -33,18 +32,9 @@ func part1(data string) string {
 	res := 0
 	for i, pair := range packetPairsStr {
 		pairItems := strings.Split(pair, "\n")
-		var left, right any
-		err := json.Unmarshal([]byte(pairItems[0]), &left)
-		if err != nil {
-			panic(err)
-		}
-		err = json.Unmarshal([]byte(pairItems[1]), &right)
-		if err != nil {
-			panic(err)
-		}
-		// left := ParsePacket(pairItems[0])
-		// right := ParsePacket(pairItems[1])
-		if ComparePackets(left, right) < 0 {
+		left := mustParsePacket(pairItems[0])
+		right := mustParsePacket(pairItems[1])
+		if left.Compare(right) < 0 {
 			res += i + 1
 		}
 	}
@@ -53,39 +43,21 @@ func part1(data string) string {
 }
 
 func part2(data string) string {
-	packetPairsStr := strings.Split(data, "\n\n")
-
-	packetPairsStr = append(packetPairsStr, "[[2]]\n[[6]]")
-
-	packets := []any{}
-	var p any
-	for _, pair := range packetPairsStr {
-		pairItems := strings.Split(pair, "\n")
-		for _, item := range pairItems {
-			err := json.Unmarshal([]byte(item), &p)
-			if err != nil {
-				panic(err)
-			}
-			packets = append(packets, p)
+	firstPacket := mustParsePacket("[[2]]")
+	secondPacket := mustParsePacket("[[6]]")
+	packets := Packets{firstPacket, secondPacket}
+	for _, line := range strings.Split(data, "\n") {
+		if line == "" {
+			continue
 		}
+		packets = append(packets, mustParsePacket(line))
 	}
 
-	sort.Slice(packets, func(i, j int) bool {
-		return ComparePackets(packets[i], packets[j]) < 0
-	})
+	sort.Sort(packets)
 
 	indexes := []int{}
-	var firstPacket, secondPacket any
-	err := json.Unmarshal([]byte("[[2]]"), &firstPacket)
-	if err != nil {
-		panic(err)
-	}
-	err = json.Unmarshal([]byte("[[6]]"), &secondPacket)
-	if err != nil {
-		panic(err)
-	}
 	for i, p := range packets {
-		if ComparePackets(p, firstPacket) == 0 || ComparePackets(p, secondPacket) == 0 {
+		if p.Compare(firstPacket) == 0 || p.Compare(secondPacket) == 0 {
 			indexes = append(indexes, i+1)
 			if len(indexes) == 2 {
 				break
@@ -96,38 +68,10 @@ func part2(data string) string {
 	return strconv.Itoa(indexes[0] * indexes[1])
 }
 
-// ComparePackets
-//
-// < 0: right order
-//
-//	0: continue
-//	> 0: wrong order
-func ComparePackets(left, right any) int {
-	var leftValues, rightValues []any
-	isLeftNum, isRightNum := false, false
-	switch val := left.(type) {
-	case float64:
-		leftValues, isLeftNum = []any{val}, true
-	case []any:
-		leftValues = val
-	}
-
-	switch val := right.(type) {
-	case float64:
-		rightValues, isRightNum = []any{val}, true
-	case []any:
-		rightValues = val
-	}
-
-	if isLeftNum && isRightNum {
-		return int(leftValues[0].(float64) - rightValues[0].(float64))
-	}
-
-	for i := 0; i < len(leftValues) && i < len(rightValues); i++ {
-		if res := ComparePackets(leftValues[i], rightValues[i]); res != 0 {
-			return res
-		}
+func mustParsePacket(line string) Packet {
+	packet, err := ParsePacket(line)
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", line, err))
 	}
-
-	return len(leftValues) - len(rightValues)
+	return packet
 }
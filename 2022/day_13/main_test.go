@@ -37,3 +37,31 @@ func TestPart1(t *testing.T) {
 func TestPart2(t *testing.T) {
 	assert.Equal(t, "140", part2(data), "Failed testing part 2")
 }
+
+func TestParsePacket(t *testing.T) {
+	packet, err := ParsePacket("[1,[2,[3,[4,[5,6,7]]]],8,9]")
+	assert.Nil(t, err)
+	assert.Equal(t, "[1,[2,[3,[4,[5,6,7]]]],8,9]", packet.String())
+	assert.Equal(t, ListPacket(ListPacket(), IntegerPacket(10)), mustParsePacket("[[],10]"))
+
+	for input, position := range map[string]int{
+		"[1,2":                   4,
+		"[1,,2]":                 3,
+		`["a"]`:                  1,
+		"[1]]":                   3,
+		"1":                      0,
+		"[99999999999999999999]": 1,
+	} {
+		_, err := ParsePacket(input)
+		var parseErr *ParseError
+		assert.ErrorAs(t, err, &parseErr, input)
+		assert.Equal(t, position, parseErr.Position, input)
+	}
+}
+
+func TestComparePackets(t *testing.T) {
+	assert.Equal(t, -1, mustParsePacket("[[1],[2,3,4]]").Compare(mustParsePacket("[[1],4]")))
+	assert.Equal(t, 1, mustParsePacket("[9]").Compare(mustParsePacket("[[8,7,6]]")))
+	assert.Equal(t, 0, mustParsePacket("[[2]]").Compare(mustParsePacket("[2]")))
+	assert.Equal(t, -1, mustParsePacket("[9007199254740992]").Compare(mustParsePacket("[9007199254740993]")))
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Packet is either an integer or a list of packets
+type Packet struct {
+	IsInteger bool
+	Value     int
+	List      []Packet
+}
+
+func IntegerPacket(value int) Packet {
+	return Packet{IsInteger: true, Value: value}
+}
+
+func ListPacket(items ...Packet) Packet {
+	return Packet{List: items}
+}
+
+type ParseError struct {
+	Position int
+	Message  string
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("position %d: %s", err.Position, err.Message)
+}
+
+type packetParser struct {
+	input    string
+	position int
+}
+
+// ParsePacket parses a list of integers and lists, like [1,[2,3],[]]
+func ParsePacket(input string) (Packet, error) {
+	parser := &packetParser{input: input}
+	if parser.peek() != '[' {
+		return Packet{}, parser.errorf("packet must start with '['")
+	}
+	packet, err := parser.parse()
+	if err != nil {
+		return Packet{}, err
+	}
+	if parser.position < len(input) {
+		return Packet{}, parser.errorf("unexpected %q after packet", input[parser.position])
+	}
+	return packet, nil
+}
+
+func (parser *packetParser) errorf(format string, args ...any) *ParseError {
+	return &ParseError{Position: parser.position, Message: fmt.Sprintf(format, args...)}
+}
+
+// next byte, 0 at the end of the input
+func (parser *packetParser) peek() byte {
+	if parser.position >= len(parser.input) {
+		return 0
+	}
+	return parser.input[parser.position]
+}
+
+func (parser *packetParser) parse() (Packet, error) {
+	switch c := parser.peek(); {
+	case c == '[':
+		return parser.parseList()
+	case c >= '0' && c <= '9':
+		return parser.parseInteger()
+	case c == 0:
+		return Packet{}, parser.errorf("unexpected end of packet")
+	default:
+		return Packet{}, parser.errorf("unexpected %q", c)
+	}
+}
+
+func (parser *packetParser) parseList() (Packet, error) {
+	// skip [
+	parser.position++
+	packet := ListPacket()
+	if parser.peek() == ']' {
+		parser.position++
+		return packet, nil
+	}
+	for {
+		item, err := parser.parse()
+		if err != nil {
+			return Packet{}, err
+		}
+		packet.List = append(packet.List, item)
+		switch c := parser.peek(); c {
+		case ',':
+			parser.position++
+		case ']':
+			parser.position++
+			return packet, nil
+		case 0:
+			return Packet{}, parser.errorf("unexpected end of packet, expected ',' or ']'")
+		default:
+			return Packet{}, parser.errorf("unexpected %q, expected ',' or ']'", c)
+		}
+	}
+}
+
+func (parser *packetParser) parseInteger() (Packet, error) {
+	start := parser.position
+	for c := parser.peek(); c >= '0' && c <= '9'; c = parser.peek() {
+		parser.position++
+	}
+	value, err := strconv.Atoi(parser.input[start:parser.position])
+	if err != nil {
+		return Packet{}, &ParseError{Position: start, Message: "integer out of range"}
+	}
+	return IntegerPacket(value), nil
+}
+
+func (packet Packet) String() string {
+	if packet.IsInteger {
+		return strconv.Itoa(packet.Value)
+	}
+	items := []string{}
+	for _, item := range packet.List {
+		items = append(items, item.String())
+	}
+	return "[" + strings.Join(items, ",") + "]"
+}
+
+// Compare
+//
+// < 0: right order
+//
+//	0: continue
+//	> 0: wrong order
+func (packet Packet) Compare(other Packet) int {
+	if packet.IsInteger && other.IsInteger {
+		switch {
+		case packet.Value < other.Value:
+			return -1
+		case packet.Value > other.Value:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	left, right := packet.List, other.List
+	if packet.IsInteger {
+		left = []Packet{packet}
+	}
+	if other.IsInteger {
+		right = []Packet{other}
+	}
+	for i := 0; i < len(left) && i < len(right); i++ {
+		if res := left[i].Compare(right[i]); res != 0 {
+			return res
+		}
+	}
+	switch {
+	case len(left) < len(right):
+		return -1
+	case len(left) > len(right):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Packets sorts in the right order
+type Packets []Packet
+
+func (packets Packets) Len() int           { return len(packets) }
+func (packets Packets) Less(i, j int) bool { return packets[i].Compare(packets[j]) < 0 }
+func (packets Packets) Swap(i, j int)      { packets[i], packets[j] = packets[j], packets[i] }
@@ -5,10 +5,13 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"image/color"
 	"math"
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/pducolin/advent-of-code/2022/common"
 )
 
 //go:embed input.txt
@@ -16,11 +19,24 @@ var inputData string
 
 func main() {
 	var part int
+	var render string
 	flag.IntVar(&part, "part", 1, "part 1 or 2")
+	flag.StringVar(&render, "render", "", "write the sand piling up to this GIF, or PNG for the final state")
 	flag.Parse()
 
 	fmt.Println("Running part", part)
 
+	if render != "" {
+		mode := ABYSS
+		if part == 2 {
+			mode = INFINITE_FLOOR
+		}
+		if err := animate(inputData, mode, render); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	if part == 1 {
 		fmt.Println(part1(inputData))
 	} else {
@@ -29,41 +45,44 @@ func main() {
 }
 
 func part1(data string) string {
-	caveMap := NewCaveMap(data)
-
-	sandCounter := 0
-	for {
-		err := caveMap.DropSand()
-		if err != nil {
-			break
-		}
-		sandCounter++
-	}
-
-	return strconv.Itoa(sandCounter)
+	simulator := NewSimulator(NewCaveMap(data), ABYSS, SAND_SOURCE)
+	return strconv.Itoa(simulator.Run())
 }
 
 func part2(data string) string {
-	caveMap := NewCaveMap(data)
+	simulator := NewSimulator(NewCaveMap(data), INFINITE_FLOOR, SAND_SOURCE)
+	return strconv.Itoa(simulator.Run())
+}
 
-	caveMap.YMax += 2
-	caveMap.XMin = 0
-	caveMap.XMax *= 2
+var SAND_SOURCE = Point{X: 500, Y: 0}
 
-	for x := caveMap.XMin; x <= caveMap.XMax; x++ {
-		caveMap.Points[Point{X: x, Y: caveMap.YMax}] = '#'
-	}
+var PALETTE = common.Palette{
+	Background: color.Black,
+	Colors: map[rune]color.Color{
+		'#': color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff},
+		'o': color.RGBA{R: 0xf4, G: 0xd0, B: 0x3f, A: 0xff},
+	},
+}
 
-	sandCounter := 0
-	for {
-		err := caveMap.DropSand()
-		if err != nil {
+// record the cave every few grains of sand, within the bounds of the final
+// pile so that frames line up
+func animate(data string, mode FloorMode, path string) error {
+	final := NewSimulator(NewCaveMap(data), mode, SAND_SOURCE)
+	final.Run()
+	xMin, xMax, yMax := final.Cave.XMin, final.Cave.XMax, final.Cave.YMax
+
+	simulator := NewSimulator(NewCaveMap(data), mode, SAND_SOURCE)
+	animation := common.NewAnimation(PALETTE, 2, 2)
+	for grains := 0; ; grains++ {
+		if _, err := simulator.DropSand(0); err != nil {
 			break
 		}
-		sandCounter++
+		if grains%100 == 0 {
+			animation.AddFrame(simulator.Cave.Render(xMin, xMax, yMax))
+		}
 	}
-
-	return strconv.Itoa(sandCounter)
+	animation.AddFrame(simulator.Cave.Render(xMin, xMax, yMax))
+	return animation.WriteFile(path)
 }
 
 type Point struct {
@@ -145,10 +164,16 @@ func (caveMap *CaveMap) UpdateBoundaries(point Point) {
 	}
 }
 
-func (caveMap *CaveMap) Print() {
-	for y := 0; y <= caveMap.YMax; y++ {
+func (caveMap *CaveMap) ToString() []string {
+	return caveMap.Render(caveMap.XMin, caveMap.XMax, caveMap.YMax)
+}
+
+// draw the cave between the given columns, from the top down to yMax
+func (caveMap *CaveMap) Render(xMin, xMax, yMax int) []string {
+	lines := []string{}
+	for y := 0; y <= yMax; y++ {
 		s := ""
-		for x := caveMap.XMin; x <= caveMap.XMax; x++ {
+		for x := xMin; x <= xMax; x++ {
 			point := Point{X: x, Y: y}
 			if r, found := caveMap.Points[point]; found {
 				s += string(r)
@@ -156,61 +181,129 @@ func (caveMap *CaveMap) Print() {
 				s += "."
 			}
 		}
-		fmt.Println(s)
+		lines = append(lines, s)
+	}
+	return lines
+}
+
+func (caveMap *CaveMap) Print() {
+	for _, line := range caveMap.ToString() {
+		fmt.Println(line)
 	}
 }
 
-func (caveMap *CaveMap) DropSand() (err error) {
-	sandPoint := Point{X: 500, Y: 0}
+var (
+	ErrAbyss         = errors.New("sand falls into the abyss")
+	ErrSourceBlocked = errors.New("sand source is blocked")
+)
+
+type FloorMode int
+
+const (
+	// sand falling below the lowest rock is lost
+	ABYSS FloorMode = iota
+	// an endless floor lies two units below the lowest rock
+	INFINITE_FLOOR
+)
 
-	outOfBoundaryErr := errors.New("out of boundary")
+// Simulator pours sand from one or more sources. Each source remembers the
+// path of its previous grain: the next grain follows the same path down to
+// where the previous one came to rest, so it resumes from the step before.
+type Simulator struct {
+	Cave    *CaveMap
+	Sources []Point
+	Mode    FloorMode
+	// y of the floor, or below which sand is in the abyss
+	floorY int
+	abyssY int
+	paths  [][]Point
+}
 
-	if _, found := caveMap.Points[sandPoint]; found {
-		return errors.New("no more space")
+func NewSimulator(cave *CaveMap, mode FloorMode, sources ...Point) *Simulator {
+	simulator := &Simulator{
+		Cave:    cave,
+		Sources: sources,
+		Mode:    mode,
+		floorY:  cave.YMax + 2,
+		abyssY:  cave.YMax,
+		paths:   make([][]Point, len(sources)),
 	}
+	for i, source := range sources {
+		simulator.paths[i] = []Point{source}
+	}
+	return simulator
+}
 
-	for {
-		// try move down
-		downPoint := Point{X: sandPoint.X, Y: sandPoint.Y + 1}
+func (simulator *Simulator) isFree(point Point) bool {
+	if simulator.Mode == INFINITE_FLOOR && point.Y >= simulator.floorY {
+		return false
+	}
+	_, found := simulator.Cave.Points[point]
+	return !found
+}
 
-		if downPoint.Y > caveMap.YMax {
-			return outOfBoundaryErr
-		}
+// drop a grain of sand from a source, returning where it comes to rest
+func (simulator *Simulator) DropSand(sourceIndex int) (Point, error) {
+	path := simulator.paths[sourceIndex]
+	// discard the steps now filled by other grains
+	for len(path) > 0 && !simulator.isFree(path[len(path)-1]) {
+		path = path[:len(path)-1]
+	}
+	if len(path) == 0 {
+		simulator.paths[sourceIndex] = path
+		return Point{}, ErrSourceBlocked
+	}
 
-		if _, found := caveMap.Points[downPoint]; !found {
-			// we can move down, continue
-			sandPoint = downPoint
-			continue
+	for {
+		sandPoint := path[len(path)-1]
+		if simulator.Mode == ABYSS && sandPoint.Y >= simulator.abyssY {
+			simulator.paths[sourceIndex] = path
+			return Point{}, ErrAbyss
 		}
-		// try move down left
-		isOutOfBoundariesLeft := false
-		downLeftPoint := Point{X: sandPoint.X - 1, Y: sandPoint.Y + 1}
-
-		if downLeftPoint.X < caveMap.XMin || downLeftPoint.Y > caveMap.YMax {
-			isOutOfBoundariesLeft = true
-		} else {
-			if _, found := caveMap.Points[downLeftPoint]; !found {
-				// we can move down, continue
-				sandPoint = downLeftPoint
-				continue
+		moved := false
+		// down, down left, down right
+		for _, dx := range []int{0, -1, 1} {
+			next := Point{X: sandPoint.X + dx, Y: sandPoint.Y + 1}
+			if simulator.isFree(next) {
+				path = append(path, next)
+				moved = true
+				break
 			}
 		}
-		// try move down right
-		downRightPoint := Point{X: sandPoint.X + 1, Y: sandPoint.Y + 1}
-		if downRightPoint.X > caveMap.XMax || downRightPoint.Y > caveMap.YMax {
-			return outOfBoundaryErr
-		}
-		if _, found := caveMap.Points[downRightPoint]; !found {
-			// we can move down, continue
-			sandPoint = downRightPoint
+		if moved {
 			continue
 		}
-		if isOutOfBoundariesLeft {
-			return outOfBoundaryErr
-		}
 
-		// cannot move, set sand point and return
-		caveMap.Points[sandPoint] = 'o'
-		return nil
+		// cannot move, set sand point and resume next grain from the previous step
+		simulator.Cave.Points[sandPoint] = 'o'
+		simulator.Cave.UpdateBoundaries(sandPoint)
+		simulator.paths[sourceIndex] = path[:len(path)-1]
+		return sandPoint, nil
+	}
+}
+
+// pour sand from all sources in turn, until a grain falls into the abyss or
+// all sources are blocked, and return the grains at rest
+func (simulator *Simulator) Run() int {
+	sandCounter := 0
+	blocked := make([]bool, len(simulator.Sources))
+	blockedCounter := 0
+	for blockedCounter < len(simulator.Sources) {
+		for i := range simulator.Sources {
+			if blocked[i] {
+				continue
+			}
+			_, err := simulator.DropSand(i)
+			if err == ErrAbyss {
+				return sandCounter
+			}
+			if err == ErrSourceBlocked {
+				blocked[i] = true
+				blockedCounter++
+				continue
+			}
+			sandCounter++
+		}
 	}
+	return sandCounter
 }
@@ -16,3 +16,25 @@ func TestPart1(t *testing.T) {
 func TestPart2(t *testing.T) {
 	assert.Equal(t, "93", part2(data), "Failed testing part 2")
 }
+
+func TestSimulator(t *testing.T) {
+	simulator := NewSimulator(NewCaveMap(data), ABYSS, SAND_SOURCE)
+	point, err := simulator.DropSand(0)
+	assert.Nil(t, err)
+	assert.Equal(t, Point{X: 500, Y: 8}, point)
+	point, err = simulator.DropSand(0)
+	assert.Nil(t, err)
+	assert.Equal(t, Point{X: 499, Y: 8}, point)
+
+	simulator = NewSimulator(NewCaveMap(data), INFINITE_FLOOR, SAND_SOURCE)
+	assert.Equal(t, 93, simulator.Run())
+	_, err = simulator.DropSand(0)
+	assert.Equal(t, ErrSourceBlocked, err)
+
+	// two sources share the pile
+	simulator = NewSimulator(NewCaveMap(data), INFINITE_FLOOR, Point{X: 500, Y: 0}, Point{X: 490, Y: 5})
+	count := simulator.Run()
+	assert.Greater(t, count, 93)
+	_, err = simulator.DropSand(1)
+	assert.Equal(t, ErrSourceBlocked, err)
+}
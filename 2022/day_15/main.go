@@ -5,9 +5,8 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"math"
+	"os"
 	"regexp"
-	"sort"
 	"strconv"
 	"strings"
 )
@@ -17,32 +16,72 @@ var inputData string
 
 func main() {
 	var part int
+	var render string
 	flag.IntVar(&part, "part", 1, "part 1 or 2")
+	flag.StringVar(&render, "render", "", "write a PNG overview of the sensor field to this file")
 	flag.Parse()
 
+	if render != "" {
+		if err := renderField(inputData, render); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	fmt.Println("Running part", part)
 
 	if part == 1 {
 		fmt.Println(part1(inputData, 2000000))
 	} else {
-		fmt.Println(part2(inputData, 0, 4000000))
+		fmt.Println(part2(inputData, 0, 4000000, MultiplierFrequency(4000000)))
 	}
 }
 
+func renderField(data string, path string) error {
+	field := NewSensorField(parseData(data))
+	min, max := field.Bounds()
+	// at most 1000 pixels along the longest side
+	side := max.x - min.x
+	if max.y-min.y > side {
+		side = max.y - min.y
+	}
+	scale := side/1000 + 1
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := field.WritePNG(file, min, max, scale); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
 func part1(data string, y int) string {
-	beaconMap := NewBeaconMap(data, y)
-	res := beaconMap.CountImpossibleBeaconAt(y)
+	field := NewSensorField(parseData(data))
+	// positions where a beacon cannot be: sensors and known beacons are
+	// covered but excluded
+	res := field.CoverageAt(y) - len(field.BeaconsAt(y)) - len(field.SensorsAt(y))
 	return strconv.Itoa(res)
 }
 
-// part2 solution inspired by https://github.com/camaron-ai/adventofcode-2022/blob/b55b74b1a0e3d64de8e5674952201d10e8216f86/day15/main.py
-func part2(data string, min, max int) string {
-	fmt.Println("Start creating map")
-	input := parseData(data)
-	fmt.Println("Look for beacon")
-	res := FindBeacon(input, min, max)
-	fmt.Println("Beacon found")
-	return strconv.Itoa(res.x*4000000 + res.y)
+func part2(data string, min, max int, frequency FrequencyFormula) string {
+	field := NewSensorField(parseData(data))
+	beacon, err := field.FindDistressBeacon(min, max)
+	if err != nil {
+		panic(err)
+	}
+	return strconv.Itoa(frequency(beacon))
+}
+
+// tuning frequency of the distress beacon
+type FrequencyFormula func(beacon Point) int
+
+func MultiplierFrequency(multiplier int) FrequencyFormula {
+	return func(beacon Point) int {
+		return beacon.x*multiplier + beacon.y
+	}
 }
 
 type Point struct {
@@ -61,15 +100,6 @@ func abs(num int) int {
 	return num
 }
 
-type BeaconMap struct {
-	Points   map[Point]rune
-	Segments []Segment
-	xMin     int
-	xMax     int
-	yMin     int
-	yMax     int
-}
-
 type SensorBeacon struct {
 	sensor Point
 	beacon Point
@@ -84,134 +114,68 @@ func parseData(data string) []SensorBeacon {
 	return ret
 }
 
-func NewBeaconMap(data string, targetY int) (beaconMap *BeaconMap) {
-	beaconMap = &BeaconMap{
-		Points: map[Point]rune{},
-		xMin:   math.MaxInt,
-		xMax:   math.MinInt,
-		yMin:   math.MaxInt,
-		yMax:   math.MinInt,
-	}
-
-	for _, line := range strings.Split(data, "\n") {
-		sensor, beacon := parseLine(line)
-		beaconMap.Points[sensor] = 'S'
-		beaconMap.Points[beacon] = 'B'
-		// fill map with no beacon points
-		distance := sensor.manhattanDistance(beacon)
-		if targetY >= sensor.y-distance && targetY <= sensor.y+distance {
-			for x := sensor.x - distance; x <= sensor.x+distance; x++ {
-				point := Point{x: x, y: targetY}
-				if point.manhattanDistance(sensor) > distance {
-					continue
-				}
-				if _, found := beaconMap.Points[point]; found {
-					continue
-				}
-				beaconMap.Points[point] = '#'
-			}
-		}
-		// update xMin, xMax
-		if beaconMap.xMin > sensor.x-distance {
-			beaconMap.xMin = sensor.x - distance
-		}
-		if beaconMap.xMax < sensor.x+distance {
-			beaconMap.xMax = sensor.x + distance
-		}
-		// update yMin, yMax
-		if beaconMap.yMin > sensor.y-distance {
-			beaconMap.yMin = sensor.y - distance
-		}
-		if beaconMap.yMax < sensor.y+distance {
-			beaconMap.yMax = sensor.y + distance
-		}
-	}
-
-	return beaconMap
-}
-
 type Segment struct {
 	xMin int
 	xMax int
 }
 
-func evaluateIntervalsAtY(input []SensorBeacon, min, max, y int) []Segment {
-	intervals := []Segment{}
-	for _, sensorBeacon := range input {
-		sensor := sensorBeacon.sensor
-		beacon := sensorBeacon.beacon
-		distanceFromBeacon := sensor.manhattanDistance(beacon)
-		distanceFromY := sensor.manhattanDistance(Point{sensor.x, y})
-		if distanceFromY <= distanceFromBeacon {
-			// interval of beacons
-			delta := distanceFromBeacon - distanceFromY
-			newInterval := Segment{
-				xMin: sensorBeacon.sensor.x - delta,
-				xMax: sensorBeacon.sensor.x + delta,
-			}
+func (sensorBeacon SensorBeacon) radius() int {
+	return sensorBeacon.sensor.manhattanDistance(sensorBeacon.beacon)
+}
 
-			if newInterval.xMin <= min && newInterval.xMax >= max {
-				return []Segment{newInterval}
-			}
+func (sensorBeacon SensorBeacon) covers(point Point) bool {
+	return sensorBeacon.sensor.manhattanDistance(point) <= sensorBeacon.radius()
+}
 
-			intervals = append(intervals, newInterval)
-		}
+// The distress beacon is the only uncovered point in the search square, so
+// unless it lies on the square border it sits right outside the diamonds of
+// at least two sensors: at the intersection of a x+y=a line and a x-y=b line
+// bounding them. Only those intersections, the border crossings of the same
+// lines and the square corners need to be checked.
+func (field *SensorField) FindDistressBeacon(min, max int) (Point, error) {
+	ascending := map[int]struct{}{}  // x+y=a
+	descending := map[int]struct{}{} // x-y=b
+	for _, sensorBeacon := range field.sensors {
+		sensor := sensorBeacon.sensor
+		radius := sensorBeacon.radius() + 1
+		ascending[sensor.x+sensor.y-radius] = struct{}{}
+		ascending[sensor.x+sensor.y+radius] = struct{}{}
+		descending[sensor.x-sensor.y-radius] = struct{}{}
+		descending[sensor.x-sensor.y+radius] = struct{}{}
 	}
-	return intervals
-}
 
-func FindBeacon(input []SensorBeacon, min, max int) Point {
-	for y := min; y <= max; y++ {
-		intervals := evaluateIntervalsAtY(input, min, max, y)
-		// sort intervals
-		sort.Slice(intervals, func(i, j int) bool {
-			return intervals[i].xMin < intervals[j].xMin
-		})
-
-		interval := intervals[0]
-		disjointIntervals := []Segment{}
-		for _, otherInterval := range intervals {
-			if interval.xMax >= otherInterval.xMin {
-				if interval.xMax < otherInterval.xMax {
-					interval.xMax = otherInterval.xMax
-				}
+	candidates := []Point{
+		{x: min, y: min},
+		{x: min, y: max},
+		{x: max, y: min},
+		{x: max, y: max},
+	}
+	for a := range ascending {
+		for b := range descending {
+			if (a-b)%2 != 0 {
 				continue
 			}
-			disjointIntervals = append(disjointIntervals, interval)
-			interval = otherInterval
-		}
-		disjointIntervals = append(disjointIntervals, interval)
-		if len(disjointIntervals) > 1 {
-			x := disjointIntervals[0].xMax + 1
-			fmt.Printf("Found point at [%d,%d]", x, y)
-			return Point{x: x, y: y}
+			candidates = append(candidates, Point{x: (a + b) / 2, y: (a - b) / 2})
 		}
 	}
-	panic(errors.New("no spot found"))
-}
-
-func (beaconMap *BeaconMap) CountImpossibleBeaconAt(y int) int {
-	counter := 0
-	for x := beaconMap.xMin; x <= beaconMap.xMax; x++ {
-		if r, found := beaconMap.Points[Point{x: x, y: y}]; found {
-			if r == '#' {
-				counter += 1
-			}
+	for _, border := range []int{min, max} {
+		for a := range ascending {
+			candidates = append(candidates, Point{x: border, y: a - border}, Point{x: a - border, y: border})
+		}
+		for b := range descending {
+			candidates = append(candidates, Point{x: border, y: border - b}, Point{x: b + border, y: border})
 		}
 	}
-	return counter
-}
 
-func (beaconMap *BeaconMap) FindBeacon(min, max int) Point {
-	for x := min; x <= max; x++ {
-		for y := min; y <= max; y++ {
-			point := Point{x: x, y: y}
-			if _, found := beaconMap.Points[point]; !found {
-				return point
-			}
+	for _, candidate := range candidates {
+		if candidate.x < min || candidate.x > max || candidate.y < min || candidate.y > max {
+			continue
+		}
+		if !field.Covers(candidate) {
+			return candidate, nil
 		}
 	}
-	panic(errors.New("no spot found"))
+	return Point{}, errors.New("no spot found")
 }
 
 const lineRegExpStr = `Sensor at x=(-?\d+), y=(-?\d+): closest beacon is at x=(-?\d+), y=(-?\d+)`
@@ -237,17 +201,3 @@ func parseLine(line string) (sensorPoint Point, beaconPoint Point) {
 
 	return Point{x: nums[0], y: nums[1]}, Point{x: nums[2], y: nums[3]}
 }
-
-func (beaconMap *BeaconMap) Print() {
-	for y := beaconMap.yMin; y <= beaconMap.yMax; y++ {
-		s := ""
-		for x := beaconMap.xMin; x <= beaconMap.xMax; x++ {
-			if r, found := beaconMap.Points[Point{x: x, y: y}]; found {
-				s += string(r)
-				continue
-			}
-			s += "."
-		}
-		fmt.Println(s)
-	}
-}
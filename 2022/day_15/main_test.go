@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -25,6 +26,47 @@ func TestPart1(t *testing.T) {
 	assert.Equal(t, "26", part1(data, 10), "Failed testing part 1")
 }
 
+func TestPart1SensorsOnRow(t *testing.T) {
+	// sensors at x=8 and x=16 sit on row 7, a beacon cannot be there either
+	field := NewSensorField(parseData(data))
+	assert.Equal(t, []Point{{x: 8, y: 7}, {x: 16, y: 7}}, field.SensorsAt(7))
+	count := 0
+	for x := -100; x <= 100; x++ {
+		point := Point{x: x, y: 7}
+		if point == (Point{x: 8, y: 7}) || point == (Point{x: 16, y: 7}) {
+			continue
+		}
+		if field.Covers(point) {
+			count++
+		}
+	}
+	assert.Equal(t, field.CoverageAt(7)-2, count)
+	assert.Equal(t, strconv.Itoa(count), part1(data, 7))
+}
+
 func TestPart2(t *testing.T) {
-	assert.Equal(t, "56000011", part2(data, 0, 20), "Failed testing part 2")
+	assert.Equal(t, "56000011", part2(data, 0, 20, MultiplierFrequency(4000000)), "Failed testing part 2")
+}
+
+func TestFindDistressBeacon(t *testing.T) {
+	beacon, err := NewSensorField(parseData(data)).FindDistressBeacon(0, 20)
+	assert.Nil(t, err)
+	assert.Equal(t, Point{x: 14, y: 11}, beacon)
+
+	_, err = NewSensorField(parseData(data)).FindDistressBeacon(0, 5)
+	assert.NotNil(t, err)
+}
+
+func TestSensorField(t *testing.T) {
+	field := NewSensorField(parseData(data))
+
+	assert.True(t, field.Covers(Point{x: 8, y: 7}))
+	assert.False(t, field.Covers(Point{x: 14, y: 11}))
+	assert.Equal(t, 27, field.CoverageAt(10))
+	assert.Equal(t, []Point{{x: 2, y: 10}}, field.BeaconsAt(10))
+	assert.Equal(t, []Point{{x: 14, y: 11}}, field.UncoveredIn(Point{x: 0, y: 0}, Point{x: 20, y: 20}))
+	assert.Equal(t, []SensorBeacon{{sensor: Point{x: 2, y: 0}, beacon: Point{x: 2, y: 10}}}, field.CoveringSensors(Point{x: 2, y: -10}))
+	assert.Len(t, field.CoveringSensors(Point{x: 8, y: -2}), 2)
+	assert.Equal(t, "S", field.Render(Point{x: 8, y: 7}, Point{x: 8, y: 7}, 1)[:1])
+	assert.Equal(t, ".#.\n", field.Render(Point{x: 1, y: -10}, Point{x: 3, y: -10}, 1))
 }
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// SensorField answers coverage queries over the diamonds of all sensors
+type SensorField struct {
+	sensors []SensorBeacon
+	beacons map[Point]struct{}
+}
+
+func NewSensorField(input []SensorBeacon) *SensorField {
+	field := &SensorField{
+		sensors: input,
+		beacons: map[Point]struct{}{},
+	}
+	for _, sensorBeacon := range input {
+		field.beacons[sensorBeacon.beacon] = struct{}{}
+	}
+	return field
+}
+
+func (field *SensorField) Covers(point Point) bool {
+	for _, sensorBeacon := range field.sensors {
+		if sensorBeacon.covers(point) {
+			return true
+		}
+	}
+	return false
+}
+
+// sensors whose diamond contains the point
+func (field *SensorField) CoveringSensors(point Point) []SensorBeacon {
+	ret := []SensorBeacon{}
+	for _, sensorBeacon := range field.sensors {
+		if sensorBeacon.covers(point) {
+			ret = append(ret, sensorBeacon)
+		}
+	}
+	return ret
+}
+
+// sorted and disjoint covered segments of row y
+func (field *SensorField) SegmentsAt(y int) []Segment {
+	segments := []Segment{}
+	for _, sensorBeacon := range field.sensors {
+		delta := sensorBeacon.radius() - abs(sensorBeacon.sensor.y-y)
+		if delta < 0 {
+			continue
+		}
+		segments = append(segments, Segment{
+			xMin: sensorBeacon.sensor.x - delta,
+			xMax: sensorBeacon.sensor.x + delta,
+		})
+	}
+	if len(segments) == 0 {
+		return segments
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].xMin < segments[j].xMin
+	})
+	merged := []Segment{segments[0]}
+	for _, segment := range segments[1:] {
+		last := &merged[len(merged)-1]
+		if segment.xMin <= last.xMax+1 {
+			if segment.xMax > last.xMax {
+				last.xMax = segment.xMax
+			}
+			continue
+		}
+		merged = append(merged, segment)
+	}
+	return merged
+}
+
+// number of covered points on row y, sensors and known beacons included
+func (field *SensorField) CoverageAt(y int) int {
+	count := 0
+	for _, segment := range field.SegmentsAt(y) {
+		count += segment.xMax - segment.xMin + 1
+	}
+	return count
+}
+
+// known beacons on row y
+func (field *SensorField) BeaconsAt(y int) []Point {
+	ret := []Point{}
+	for beacon := range field.beacons {
+		if beacon.y == y {
+			ret = append(ret, beacon)
+		}
+	}
+	return ret
+}
+
+// sensors on row y, each inside its own diamond
+func (field *SensorField) SensorsAt(y int) []Point {
+	ret := []Point{}
+	for _, sensorBeacon := range field.sensors {
+		if sensorBeacon.sensor.y == y {
+			ret = append(ret, sensorBeacon.sensor)
+		}
+	}
+	return ret
+}
+
+// uncovered points in the rectangle between min and max, both included
+func (field *SensorField) UncoveredIn(min, max Point) []Point {
+	ret := []Point{}
+	for y := min.y; y <= max.y; y++ {
+		x := min.x
+		for _, segment := range field.SegmentsAt(y) {
+			for ; x < segment.xMin && x <= max.x; x++ {
+				ret = append(ret, Point{x: x, y: y})
+			}
+			if x <= segment.xMax {
+				x = segment.xMax + 1
+			}
+		}
+		for ; x <= max.x; x++ {
+			ret = append(ret, Point{x: x, y: y})
+		}
+	}
+	return ret
+}
+
+// smallest rectangle containing all the sensor diamonds
+func (field *SensorField) Bounds() (min, max Point) {
+	min = Point{x: math.MaxInt, y: math.MaxInt}
+	max = Point{x: math.MinInt, y: math.MinInt}
+	for _, sensorBeacon := range field.sensors {
+		sensor := sensorBeacon.sensor
+		radius := sensorBeacon.radius()
+		if min.x > sensor.x-radius {
+			min.x = sensor.x - radius
+		}
+		if max.x < sensor.x+radius {
+			max.x = sensor.x + radius
+		}
+		if min.y > sensor.y-radius {
+			min.y = sensor.y - radius
+		}
+		if max.y < sensor.y+radius {
+			max.y = sensor.y + radius
+		}
+	}
+	return min, max
+}
+
+// rune of the scale x scale block starting at the given corner: sensors and
+// beacons win over coverage, which is sampled at the block center
+func (field *SensorField) blockRune(corner Point, scale int) rune {
+	inBlock := func(point Point) bool {
+		return point.x >= corner.x && point.x < corner.x+scale &&
+			point.y >= corner.y && point.y < corner.y+scale
+	}
+	for _, sensorBeacon := range field.sensors {
+		if inBlock(sensorBeacon.sensor) {
+			return 'S'
+		}
+	}
+	for beacon := range field.beacons {
+		if inBlock(beacon) {
+			return 'B'
+		}
+	}
+	if field.Covers(Point{x: corner.x + scale/2, y: corner.y + scale/2}) {
+		return '#'
+	}
+	return '.'
+}
+
+// ASCII overview of the rectangle between min and max, one character per
+// scale x scale block
+func (field *SensorField) Render(min, max Point, scale int) string {
+	var sb strings.Builder
+	for y := min.y; y <= max.y; y += scale {
+		for x := min.x; x <= max.x; x += scale {
+			sb.WriteRune(field.blockRune(Point{x: x, y: y}, scale))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+var runeColors = map[rune]color.Color{
+	'S': color.RGBA{R: 0xd6, G: 0x27, B: 0x28, A: 0xff},
+	'B': color.RGBA{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff},
+	'#': color.Gray{Y: 0x60},
+	'.': color.White,
+}
+
+// PNG overview of the rectangle between min and max, one pixel per
+// scale x scale block
+func (field *SensorField) WritePNG(w io.Writer, min, max Point, scale int) error {
+	width := (max.x-min.x)/scale + 1
+	height := (max.y-min.y)/scale + 1
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for row := 0; row < height; row++ {
+		for col := 0; col < width; col++ {
+			r := field.blockRune(Point{x: min.x + col*scale, y: min.y + row*scale}, scale)
+			img.Set(col, row, runeColors[r])
+		}
+	}
+	return png.Encode(w, img)
+}
@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"flag"
 	"fmt"
+	"os"
 	"regexp"
 	"sort"
 	"strconv"
@@ -17,11 +18,26 @@ var inputData string
 
 func main() {
 	var part int
+	var format string
 	flag.IntVar(&part, "part", 1, "part 1 or 2")
+	flag.StringVar(&format, "schedule", "", "print the valve-opening schedule as table or json")
 	flag.Parse()
 
 	fmt.Println("Running part", part)
 
+	if format != "" {
+		var schedule Schedule
+		if part == 1 {
+			schedule = soloSchedule(inputData)
+		} else {
+			schedule = duoSchedule(inputData)
+		}
+		if err := schedule.Write(os.Stdout, format); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	if part == 1 {
 		fmt.Println(part1(inputData))
 	} else {
@@ -31,32 +47,42 @@ func main() {
 
 // both parts inspired by @bhosale-ajay
 func part1(data string) string {
+	return strconv.Itoa(soloSchedule(data).Released)
+}
+
+func part2(data string) string {
+	return strconv.Itoa(duoSchedule(data).Released)
+}
+
+func parseValves(data string) map[string]Valve {
 	valvesByName := map[string]Valve{}
 
 	for _, line := range strings.Split(data, "\n") {
 		valve := ParseValve(line)
 		valvesByName[valve.name] = valve
 	}
+	return valvesByName
+}
+
+// best schedule opening valves alone in 30 minutes
+func soloSchedule(data string) Schedule {
+	valvesByName := parseValves(data)
 
 	timeMap, interestingValves := BuiltTimeMap(valvesByName)
 
-	ret := findBestFlow(valvesByName, timeMap, "AA", 30, interestingValves)
+	_, route := findBestFlow(valvesByName, timeMap, "AA", 30, interestingValves)
 
-	return strconv.Itoa(ret)
+	return NewSchedule(valvesByName, timeMap, 30, []AgentPlan{{Agent: "you", Valves: route}})
 }
 
-func part2(data string) string {
-	valvesByName := map[string]Valve{}
-
-	for _, line := range strings.Split(data, "\n") {
-		valve := ParseValve(line)
-		valvesByName[valve.name] = valve
-	}
+// best schedule opening valves with the help of an elephant in 26 minutes
+func duoSchedule(data string) Schedule {
+	valvesByName := parseValves(data)
 
 	timeMap, interestingValves := BuiltTimeMap(valvesByName)
 
-	bestFlowByPath := map[string]int{}
-	buildBestFlowByPath(bestFlowByPath, valvesByName, interestingValves, timeMap, "AA", 26, map[string]struct{}{}, 0)
+	bestFlowByPath := map[string]PathFlow{}
+	buildBestFlowByPath(bestFlowByPath, valvesByName, interestingValves, timeMap, "AA", 26, []string{}, 0)
 	// build elephant possible paths
 	elephantInterestingValves := []string{}
 	for valve := range interestingValves {
@@ -68,6 +94,7 @@ func part2(data string) string {
 	sort.Strings(elephantInterestingValves)
 	extendBestFlowByPath(bestFlowByPath, elephantInterestingValves)
 	result := 0
+	plans := []AgentPlan{}
 	for humanPathKey := range bestFlowByPath {
 		humanValves := pathKeyToValves(humanPathKey)
 		elephantPathKey := ""
@@ -77,13 +104,18 @@ func part2(data string) string {
 			}
 			elephantPathKey += elephantValve
 		}
-		flow := bestFlowByPath[humanPathKey] + bestFlowByPath[elephantPathKey]
+		flow := bestFlowByPath[humanPathKey].flow + bestFlowByPath[elephantPathKey].flow
 		if result < flow {
 			result = flow
+			// "you" before "elephant", like in the puzzle
+			plans = []AgentPlan{
+				{Agent: "you", Valves: bestFlowByPath[humanPathKey].route},
+				{Agent: "elephant", Valves: bestFlowByPath[elephantPathKey].route},
+			}
 		}
 	}
 
-	return strconv.Itoa(result)
+	return NewSchedule(valvesByName, timeMap, 26, plans)
 }
 
 const regexStr = `Valve ([A-Z]{2}) has flow rate=(\d+); tunnel(s)? lead(s)? to valve(s)? (([A-Z]{2},? ?)+)`
@@ -150,7 +182,8 @@ func BuiltTimeMap(valvesByName map[string]Valve) (timeMap TimeMap, interestingVa
 	return timeMap, interestingValves
 }
 
-func findBestFlow(valvesByName map[string]Valve, timeMap TimeMap, fromValve string, time int, otherValves map[string]struct{}) int {
+// best flow from a valve, with the ordered valves to open to get it
+func findBestFlow(valvesByName map[string]Valve, timeMap TimeMap, fromValve string, time int, otherValves map[string]struct{}) (int, []string) {
 	possibleValves := map[string]struct{}{}
 	// filter out starting point
 	for valve := range otherValves {
@@ -161,29 +194,41 @@ func findBestFlow(valvesByName map[string]Valve, timeMap TimeMap, fromValve stri
 	}
 
 	bestFlow := 0
+	bestRoute := []string{}
 	for nextValve := range possibleValves {
 		timeLeftAfterVisitingNext := time - timeMap[fromValve][nextValve] - 1
 		if timeLeftAfterVisitingNext > 0 {
-			flow := valvesByName[nextValve].flowRate*timeLeftAfterVisitingNext +
-				findBestFlow(valvesByName, timeMap, nextValve, timeLeftAfterVisitingNext, possibleValves)
+			nextFlow, nextRoute := findBestFlow(valvesByName, timeMap, nextValve, timeLeftAfterVisitingNext, possibleValves)
+			flow := valvesByName[nextValve].flowRate*timeLeftAfterVisitingNext + nextFlow
 			if flow > bestFlow {
 				bestFlow = flow
+				bestRoute = append([]string{nextValve}, nextRoute...)
 			}
 		}
 	}
-	return bestFlow
+	return bestFlow, bestRoute
+}
+
+// best flow for a set of opened valves, with the order they were opened in
+type PathFlow struct {
+	flow  int
+	route []string
 }
 
 func buildBestFlowByPath(
-	bestFlowByPath map[string]int,
+	bestFlowByPath map[string]PathFlow,
 	valvesByName map[string]Valve,
 	interestingValves map[string]struct{},
 	timeMap TimeMap,
 	fromValve string,
 	time int,
-	visitedValves map[string]struct{},
+	route []string,
 	pathFlow int,
 ) int {
+	visitedValves := map[string]struct{}{}
+	for _, valve := range route {
+		visitedValves[valve] = struct{}{}
+	}
 	possibleValves := []string{}
 	for valve := range interestingValves {
 		if valve == "AA" {
@@ -195,35 +240,30 @@ func buildBestFlowByPath(
 		possibleValves = append(possibleValves, valve)
 	}
 
-	visitedValveNames := []string{}
-	for valve := range visitedValves {
-		visitedValveNames = append(visitedValveNames, valve)
-	}
+	visitedValveNames := append([]string{}, route...)
 	sort.Strings(visitedValveNames)
 	pathKey := strings.Join(visitedValveNames, "")
-	if _, found := bestFlowByPath[pathKey]; !found {
-		bestFlowByPath[pathKey] = 0
-	}
-	if bestFlowByPath[pathKey] < pathFlow {
-		bestFlowByPath[pathKey] = pathFlow
+	if best, found := bestFlowByPath[pathKey]; !found || best.flow < pathFlow {
+		bestFlowByPath[pathKey] = PathFlow{
+			flow:  pathFlow,
+			route: route,
+		}
 	}
 	bestFlow := 0
 	for _, nextValve := range possibleValves {
 		timeLeft := time - timeMap[fromValve][nextValve] - 1
 		if timeLeft > 0 {
 			flow := valvesByName[nextValve].flowRate * timeLeft
-			newVisited := map[string]struct{}{}
-			for _, visitedValve := range visitedValveNames {
-				newVisited[visitedValve] = struct{}{}
-			}
-			newVisited[nextValve] = struct{}{}
+			newRoute := make([]string, len(route), len(route)+1)
+			copy(newRoute, route)
+			newRoute = append(newRoute, nextValve)
 			flow = buildBestFlowByPath(bestFlowByPath,
 				valvesByName,
 				interestingValves,
 				timeMap,
 				nextValve,
 				timeLeft,
-				newVisited,
+				newRoute,
 				flow+pathFlow)
 			if flow > bestFlow {
 				bestFlow = flow
@@ -233,11 +273,12 @@ func buildBestFlowByPath(
 	return bestFlow
 }
 
-// no idea what this does
-func extendBestFlowByPath(bestFlowByPath map[string]int, valvesInPath []string) int {
+// fill the best flow of each subset of valves not reachable in time,
+// with the best flow among its own subsets
+func extendBestFlowByPath(bestFlowByPath map[string]PathFlow, valvesInPath []string) PathFlow {
 	pathKey := strings.Join(valvesInPath, "")
 	if _, found := bestFlowByPath[pathKey]; !found {
-		bestFlow := 0
+		best := PathFlow{route: []string{}}
 		for _, valve := range valvesInPath {
 			remainingValves := []string{}
 			for _, remainingValve := range valvesInPath {
@@ -246,12 +287,12 @@ func extendBestFlowByPath(bestFlowByPath map[string]int, valvesInPath []string)
 				}
 				remainingValves = append(remainingValves, remainingValve)
 			}
-			flow := extendBestFlowByPath(bestFlowByPath, remainingValves)
-			if flow > bestFlow {
-				bestFlow = flow
+			pathFlow := extendBestFlowByPath(bestFlowByPath, remainingValves)
+			if pathFlow.flow > best.flow {
+				best = pathFlow
 			}
 		}
-		bestFlowByPath[pathKey] = bestFlow
+		bestFlowByPath[pathKey] = best
 	}
 
 	return bestFlowByPath[pathKey]
@@ -24,3 +24,33 @@ func TestPart1(t *testing.T) {
 func TestPart2(t *testing.T) {
 	assert.Equal(t, "1707", part2(data), "Failed testing part 2")
 }
+
+func TestSoloSchedule(t *testing.T) {
+	schedule := soloSchedule(data)
+
+	assert.Equal(t, 1651, schedule.Released)
+	assert.Len(t, schedule.Routes, 1)
+	openedAt := map[string]int{}
+	for _, opening := range schedule.Routes[0].Openings {
+		openedAt[opening.Valve] = opening.Minute
+	}
+	assert.Equal(t, map[string]int{"DD": 2, "BB": 5, "JJ": 9, "HH": 17, "EE": 21, "CC": 24}, openedAt)
+	assert.Equal(t, 20, schedule.FlowPerMinute[2])
+	assert.Equal(t, 81, schedule.FlowPerMinute[29])
+	assert.Equal(t, "open DD", schedule.Routes[0].ActionAt(2))
+	assert.Equal(t, "DD -> BB", schedule.Routes[0].ActionAt(3))
+}
+
+func TestDuoSchedule(t *testing.T) {
+	schedule := duoSchedule(data)
+
+	assert.Equal(t, 1707, schedule.Released)
+	assert.Len(t, schedule.Routes, 2)
+	assert.Equal(t, "you", schedule.Routes[0].Agent)
+	assert.Equal(t, "elephant", schedule.Routes[1].Agent)
+	released := 0
+	for _, flow := range schedule.FlowPerMinute {
+		released += flow
+	}
+	assert.Equal(t, 1707, released)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Travel is a move between two valves, following the shortest path of the TimeMap
+type Travel struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// first and last minute spent moving
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Opening is a valve opened by an agent, at the end of a travel
+type Opening struct {
+	Travel   Travel `json:"travel"`
+	Valve    string `json:"valve"`
+	Minute   int    `json:"minute"`
+	FlowRate int    `json:"flowRate"`
+	Released int    `json:"released"`
+}
+
+type Route struct {
+	Agent    string    `json:"agent"`
+	Openings []Opening `json:"openings"`
+	Released int       `json:"released"`
+}
+
+type Schedule struct {
+	Time   int     `json:"time"`
+	Routes []Route `json:"routes"`
+	// pressure released during each minute, starting from minute 1
+	FlowPerMinute []int `json:"flowPerMinute"`
+	Released      int   `json:"released"`
+}
+
+// AgentPlan is the order an agent opens valves in
+type AgentPlan struct {
+	Agent  string
+	Valves []string
+}
+
+// build the schedule of agents opening valves in the given order, starting
+// from AA; routes follow the order of the plans
+func NewSchedule(valvesByName map[string]Valve, timeMap TimeMap, time int, plans []AgentPlan) Schedule {
+	schedule := Schedule{
+		Time:          time,
+		Routes:        []Route{},
+		FlowPerMinute: make([]int, time),
+	}
+
+	for _, plan := range plans {
+		route := Route{
+			Agent:    plan.Agent,
+			Openings: []Opening{},
+		}
+		fromValve := "AA"
+		minute := 0
+		for _, valve := range plan.Valves {
+			travel := Travel{
+				From:  fromValve,
+				To:    valve,
+				Start: minute + 1,
+				End:   minute + timeMap[fromValve][valve],
+			}
+			minute = travel.End + 1
+			if minute >= time {
+				break
+			}
+			opening := Opening{
+				Travel:   travel,
+				Valve:    valve,
+				Minute:   minute,
+				FlowRate: valvesByName[valve].flowRate,
+				Released: valvesByName[valve].flowRate * (time - minute),
+			}
+			for openMinute := minute + 1; openMinute <= time; openMinute++ {
+				schedule.FlowPerMinute[openMinute-1] += opening.FlowRate
+			}
+			route.Openings = append(route.Openings, opening)
+			route.Released += opening.Released
+			fromValve = valve
+		}
+		schedule.Routes = append(schedule.Routes, route)
+		schedule.Released += route.Released
+	}
+
+	return schedule
+}
+
+// what the agent of a route is doing during a minute
+func (route Route) ActionAt(minute int) string {
+	for _, opening := range route.Openings {
+		if minute >= opening.Travel.Start && minute <= opening.Travel.End {
+			return fmt.Sprintf("%s -> %s", opening.Travel.From, opening.Travel.To)
+		}
+		if minute == opening.Minute {
+			return fmt.Sprintf("open %s", opening.Valve)
+		}
+	}
+	return "-"
+}
+
+func (schedule Schedule) ToTable() string {
+	header := []string{"minute"}
+	for _, route := range schedule.Routes {
+		header = append(header, route.Agent)
+	}
+	header = append(header, "flow", "released")
+	rows := [][]string{header}
+
+	released := 0
+	for minute := 1; minute <= schedule.Time; minute++ {
+		flow := schedule.FlowPerMinute[minute-1]
+		released += flow
+		row := []string{fmt.Sprint(minute)}
+		for _, route := range schedule.Routes {
+			row = append(row, route.ActionAt(minute))
+		}
+		row = append(row, fmt.Sprint(flow), fmt.Sprint(released))
+		rows = append(rows, row)
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	var sb strings.Builder
+	for _, row := range rows {
+		cells := []string{}
+		for i, cell := range row {
+			cells = append(cells, fmt.Sprintf("%-*s", widths[i], cell))
+		}
+		sb.WriteString(strings.TrimRight(strings.Join(cells, " | "), " "))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// write the schedule as a timeline table or as json
+func (schedule Schedule) Write(w io.Writer, format string) error {
+	switch format {
+	case "table":
+		_, err := io.WriteString(w, schedule.ToTable())
+		return err
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(schedule)
+	default:
+		return fmt.Errorf("unknown schedule format %q", format)
+	}
+}
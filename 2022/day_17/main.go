@@ -5,8 +5,11 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"image/color"
 	"strconv"
 	"strings"
+
+	"github.com/pducolin/advent-of-code/2022/common"
 )
 
 //go:embed input.txt
@@ -14,11 +17,20 @@ var inputData string
 
 func main() {
 	var part int
+	var render string
 	flag.IntVar(&part, "part", 1, "part 1 or 2")
+	flag.StringVar(&render, "render", "", "write the first 2022 rocks falling to this GIF, or PNG for the final state")
 	flag.Parse()
 
 	fmt.Println("Running part", part)
 
+	if render != "" {
+		if err := animate(inputData, 2022, render); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	if part == 1 {
 		fmt.Println(part1(inputData))
 	} else {
@@ -28,101 +40,152 @@ func main() {
 
 func part1(data string) string {
 	jp := ParseJetPattern(data)
-	chamber := NewChamber()
+	chamber, err := NewChamber(DefaultConfig())
+	if err != nil {
+		panic(err)
+	}
 	chamber.Play(jp, 2022)
-	return strconv.FormatInt(chamber.height+chamber.additionalHeight, 10)
+	return strconv.FormatInt(chamber.Height(), 10)
 }
 
 // from https://github.com/RascalTwo/AdventOfCode/blob/master/2022/solutions/17/solve.ts
 func part2(data string) string {
 	jp := ParseJetPattern(data)
-	chamber := NewChamber()
+	chamber, err := NewChamber(DefaultConfig())
+	if err != nil {
+		panic(err)
+	}
 	chamber.Play(jp, 1000000000000)
-	return strconv.FormatInt(chamber.height+chamber.additionalHeight, 10)
+	return strconv.FormatInt(chamber.Height(), 10)
 }
 
-type Point struct {
-	x int64
-	y int64
+var PALETTE = common.Palette{
+	Background: color.Black,
+	Colors: map[rune]color.Color{
+		'#': color.RGBA{R: 0x3f, G: 0x8f, B: 0xf4, A: 0xff},
+		'|': color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff},
+		'+': color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff},
+		'-': color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff},
+	},
 }
 
-type Tile struct {
-	Points [][]rune
-}
+const ANIMATION_LINES = 40
 
-var TILES = []Tile{
-	{
-		Points: [][]rune{
-			{'#', '#', '#', '#'},
-		},
-	},
-	{
-		Points: [][]rune{
-			{'.', '#', '.'},
-			{'#', '#', '#'},
-			{'.', '#', '.'},
-		},
-	},
-	{
-		Points: [][]rune{
-			{'.', '.', '#'},
-			{'.', '.', '#'},
-			{'#', '#', '#'},
-		},
-	},
-	{
-		Points: [][]rune{
-			{'#'},
-			{'#'},
-			{'#'},
-			{'#'},
-		},
-	},
-	{
-		Points: [][]rune{
-			{'#', '#'},
-			{'#', '#'},
-		},
-	},
+// record the top of the chamber after each rock
+func animate(data string, rocks int, path string) error {
+	config := DefaultConfig()
+	chamber, err := NewChamber(config)
+	if err != nil {
+		return err
+	}
+	jp := ParseJetPattern(data)
+	animation := common.NewAnimation(PALETTE, 4, 2)
+	emptyLine := fmt.Sprintf("|%s|", strings.Repeat(".", config.Width))
+	for i := 0; i < rocks; i++ {
+		chamber.DropRock(&jp)
+		lines := chamber.Render(ANIMATION_LINES)
+		// keep the tower at the bottom of the frame
+		for len(lines) <= ANIMATION_LINES {
+			lines = append([]string{emptyLine}, lines...)
+		}
+		animation.AddFrame(lines)
+	}
+	return animation.WriteFile(path)
 }
 
-var CHARS = []string{"🔴", "🟡", "🟢", "🔵", "🟣"}
+// rock shapes falling in turn, separated by an empty line
+const DEFAULT_SHAPES = `####
+
+.#.
+###
+.#.
+
+..#
+..#
+###
+
+#
+#
+#
+#
+
+##
+##`
+
+// masks are stored in 64 bits words
+const MAX_CHAMBER_WIDTH = 64
+
+type Config struct {
+	Shapes []Rock
+	Width  int
+	// a new rock appears SpawnX units away from the left wall and SpawnY
+	// units above the highest rock, or the floor
+	SpawnX int
+	SpawnY int
+}
 
-const EMPTY_RUNE = "🔘"
+func DefaultConfig() Config {
+	shapes, err := ParseShapes(DEFAULT_SHAPES)
+	if err != nil {
+		panic(err)
+	}
+	return Config{
+		Shapes: shapes,
+		Width:  7,
+		SpawnX: 2,
+		SpawnY: 3,
+	}
+}
 
 type Rock struct {
-	tile         Tile
-	width        int
-	height       int
-	topLeftPoint Point
-	char         string
+	// row masks bottom up, bit x set when the rock fills column x
+	rows   []uint64
+	width  int
+	height int
 }
 
-func NewRock(index int, chamberHeight int64) *Rock {
-	tile := TILES[index]
+func ParseRock(data string) (Rock, error) {
+	lines := strings.Split(strings.TrimSpace(data), "\n")
 	rock := Rock{
-		tile:   tile,
-		width:  len(tile.Points[0]),
-		height: len(tile.Points),
+		rows:   []uint64{},
+		height: len(lines),
 	}
-	rock.topLeftPoint = Point{
-		x: 2,
-		y: chamberHeight + 3 + int64(rock.height),
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimRight(lines[i], " ")
+		if len(line) > MAX_CHAMBER_WIDTH {
+			return Rock{}, fmt.Errorf("rock wider than %d units", MAX_CHAMBER_WIDTH)
+		}
+		var mask uint64
+		for x, r := range line {
+			switch r {
+			case '#':
+				mask |= 1 << x
+			case '.':
+			default:
+				return Rock{}, fmt.Errorf("unexpected character %q in rock", r)
+			}
+		}
+		if mask == 0 {
+			return Rock{}, errors.New("empty row in rock")
+		}
+		if len(line) > rock.width {
+			rock.width = len(line)
+		}
+		rock.rows = append(rock.rows, mask)
 	}
-	rock.char = CHARS[index]
-	return &rock
+	return rock, nil
 }
 
-func (r *Rock) AllPoints() []Point {
-	points := []Point{}
-	for i, row := range r.tile.Points {
-		for j, rune := range row {
-			if rune == '#' {
-				points = append(points, Point{x: r.topLeftPoint.x + int64(j), y: r.topLeftPoint.y - int64(i)})
-			}
+func ParseShapes(data string) ([]Rock, error) {
+	rocks := []Rock{}
+	for _, block := range strings.Split(strings.TrimSpace(data), "\n\n") {
+		rock, err := ParseRock(block)
+		if err != nil {
+			return nil, err
 		}
+		rocks = append(rocks, rock)
 	}
-	return points
+	return rocks, nil
 }
 
 type JetPattern struct {
@@ -150,128 +213,161 @@ func (jp *JetPattern) Reset() {
 	jp.currentJet = jp.pattern[0]
 }
 
-type Move struct {
-	rockChar string
-	x        int64
-}
-
 type Chamber struct {
-	rocksCount       int64
-	linesBottomUp    map[Point]string
+	config Config
+	// row masks bottom up, starting from row floor
+	rows []uint64
+	// rows dropped from the bottom of the chamber to bound memory
+	floor int64
+	// top of each column, 0 when empty
+	tops             []int64
 	height           int64
-	currentRockIndex int
-	currentRock      *Rock
-	emptyRow         string
 	additionalHeight int64
-	maxLines         int64
-	moves            []Move
+	rocksCount       int64
+	currentRockIndex int
+	// rows kept above the trimming check, which runs when twice as many are
+	// stored
+	maxLines int
+	trimAt   int
 }
 
-func NewChamber() *Chamber {
-	emptyRow := strings.Repeat(string(EMPTY_RUNE), 7)
-	chamber := Chamber{
-		linesBottomUp:    map[Point]string{},
-		rocksCount:       0,
-		height:           0,
-		currentRockIndex: 0,
-		currentRock:      NewRock(0, 0),
-		emptyRow:         emptyRow,
-		additionalHeight: 0,
-		maxLines:         5000,
-		moves:            []Move{},
+func NewChamber(config Config) (*Chamber, error) {
+	if config.Width < 1 || config.Width > MAX_CHAMBER_WIDTH {
+		return nil, fmt.Errorf("chamber width must be between 1 and %d", MAX_CHAMBER_WIDTH)
 	}
-
-	// bottom row
-	for x := 0; x < 7; x++ {
-		chamber.linesBottomUp[Point{x: int64(x), y: 0}] = "🖤"
+	if len(config.Shapes) == 0 {
+		return nil, errors.New("no rock shapes")
 	}
-
-	// empty rows
-	for y := 1; y < 5; y++ {
-		for x := 0; x < 7; x++ {
-			chamber.linesBottomUp[Point{x: int64(x), y: int64(y)}] = EMPTY_RUNE
+	if config.SpawnX < 0 || config.SpawnY < 0 {
+		return nil, errors.New("negative spawn offset")
+	}
+	for _, rock := range config.Shapes {
+		if config.SpawnX+rock.width > config.Width {
+			return nil, errors.New("rock does not fit the chamber at its spawn point")
 		}
 	}
-
-	return &chamber
+	return &Chamber{
+		config:           config,
+		rows:             []uint64{},
+		tops:             make([]int64, config.Width),
+		currentRockIndex: 0,
+		maxLines:         1000,
+		trimAt:           2000,
+	}, nil
 }
 
-func (c *Chamber) Print(numberOfLines int64) []string {
-	ret := []string{}
-	start := c.height
-	bottom := start - numberOfLines
-	if bottom < 0 {
-		bottom = 0
-	}
-	for y := start; y >= bottom; y-- {
-		line := ""
-		for x := 0; x < 7; x++ {
-			r := c.linesBottomUp[Point{x: int64(x), y: y}]
-			line += r
-		}
-		ret = append(ret, fmt.Sprintf("|%s|", line))
-	}
+// height of the tower, including the extrapolated cycles
+func (c *Chamber) Height() int64 {
+	return c.height + c.additionalHeight
+}
 
-	for _, line := range ret {
-		fmt.Println(line)
+func (c *Chamber) row(y int64) uint64 {
+	if y-c.floor >= int64(len(c.rows)) {
+		return 0
 	}
-
-	return ret
+	return c.rows[y-c.floor]
 }
 
-func (c *Chamber) NextRockIndex() int {
-	c.currentRockIndex = (c.currentRockIndex + 1) % len(TILES)
-	return c.currentRockIndex
+// whether the rock, with its bottom left corner at (x, y), hits a wall, the
+// floor or another rock; rows below floor were dropped because no falling
+// rock can reach them, a rock touching them is then touching a filled cell
+func (c *Chamber) collides(rock Rock, x int, y int64) bool {
+	if x < 0 || x+rock.width > c.config.Width || y < c.floor {
+		return true
+	}
+	for i, mask := range rock.rows {
+		if c.row(y+int64(i))&(mask<<x) != 0 {
+			return true
+		}
+	}
+	return false
 }
 
-func (c *Chamber) MoveLeft() {
-	points := c.currentRock.AllPoints()
-	for _, point := range points {
-		newPoint := Point{
-			x: point.x - 1,
-			y: point.y,
+// drop the next rock until it comes to rest
+func (c *Chamber) DropRock(jp *JetPattern) {
+	rock := c.config.Shapes[c.currentRockIndex]
+	x := c.config.SpawnX
+	y := c.height + int64(c.config.SpawnY)
+	for {
+		dx := -1
+		if jp.currentJet == '>' {
+			dx = 1
 		}
-		if newPoint.x < 0 {
-			return
+		jp.Next()
+		if !c.collides(rock, x+dx, y) {
+			x += dx
 		}
-		if c.linesBottomUp[newPoint] != EMPTY_RUNE {
-			return
+		if c.collides(rock, x, y-1) {
+			break
 		}
+		y--
 	}
-	c.currentRock.topLeftPoint.x -= 1
-}
 
-func (c *Chamber) MoveRight() {
-	points := c.currentRock.AllPoints()
-	for _, point := range points {
-		newPoint := Point{
-			x: point.x + 1,
-			y: point.y,
-		}
-		if newPoint.x >= 7 {
-			return
+	for i, mask := range rock.rows {
+		rowY := y + int64(i)
+		for rowY-c.floor >= int64(len(c.rows)) {
+			c.rows = append(c.rows, 0)
 		}
-		if c.linesBottomUp[newPoint] != EMPTY_RUNE {
-			return
+		c.rows[rowY-c.floor] |= mask << x
+		for column := 0; column < rock.width; column++ {
+			if mask&(1<<column) != 0 && c.tops[x+column] < rowY+1 {
+				c.tops[x+column] = rowY + 1
+			}
 		}
 	}
+	if c.height < y+int64(rock.height) {
+		c.height = y + int64(rock.height)
+	}
+	c.rocksCount++
+	c.currentRockIndex = (c.currentRockIndex + 1) % len(c.config.Shapes)
 
-	c.currentRock.topLeftPoint.x += 1
+	if len(c.rows) > c.trimAt {
+		c.trim()
+	}
 }
 
-func (c *Chamber) MoveDown() error {
-	points := c.currentRock.AllPoints()
-	for _, point := range points {
-		newPoint := Point{
-			x: point.x,
-			y: point.y - 1,
+// lowest row a falling rock can reach. Rocks only move sideways and down,
+// so every cell of a rock follows a path of empty cells going sideways or
+// down from above the tower: rows below the lowest empty cell reachable this
+// way can never be touched again.
+func (c *Chamber) lowestReachableRow() int64 {
+	full := uint64(1)<<c.config.Width - 1
+	reached := full
+	y := c.height
+	for y > c.floor {
+		empty := ^c.row(y-1) & full
+		next := reached & empty
+		if next == 0 {
+			break
 		}
-		if c.linesBottomUp[newPoint] != EMPTY_RUNE {
-			return errors.New("reached bottom")
+		for {
+			spread := (next | next<<1 | next>>1) & empty
+			if spread == next {
+				break
+			}
+			next = spread
 		}
+		reached = next
+		y--
+	}
+	return y
+}
+
+// forget the rows no rock can reach anymore
+func (c *Chamber) trim() {
+	dropped := int(c.lowestReachableRow() - c.floor)
+	if dropped > 0 {
+		c.rows = append([]uint64{}, c.rows[dropped:]...)
+		c.floor += int64(dropped)
 	}
-	c.currentRock.topLeftPoint.y -= 1
-	return nil
+	// an open well keeps every row, check again once it has grown
+	c.trimAt = len(c.rows) + c.maxLines
+}
+
+type PatternKey struct {
+	jetIndex  int
+	rockIndex int
+	profile   string
 }
 
 type Pattern struct {
@@ -279,65 +375,72 @@ type Pattern struct {
 	height     int64
 }
 
+// depth of each column from the top of the tower
+func (c *Chamber) SurfaceProfile() []int64 {
+	profile := []int64{}
+	for _, top := range c.tops {
+		profile = append(profile, c.height-top)
+	}
+	return profile
+}
+
+func (c *Chamber) BuildPatternKey(jp JetPattern) PatternKey {
+	return PatternKey{
+		jetIndex:  jp.currentIndex,
+		rockIndex: c.currentRockIndex,
+		profile:   fmt.Sprint(c.SurfaceProfile()),
+	}
+}
+
 func (chamber *Chamber) Play(jp JetPattern, totRocks int64) {
-	patterns := map[string]Pattern{}
+	patterns := map[PatternKey]Pattern{}
+	skipped := false
 	for chamber.rocksCount < totRocks {
-		if chamber.currentRock == nil {
-			chamber.NextRockIndex()
-			chamber.currentRock = NewRock(chamber.currentRockIndex, chamber.height)
+		chamber.DropRock(&jp)
+		if skipped {
+			continue
 		}
 
-		// move with jet
-		if jp.currentJet == '>' {
-			// move to the right
-			chamber.MoveRight()
-		} else {
-			// move to the left
-			chamber.MoveLeft()
+		patternKey := chamber.BuildPatternKey(jp)
+		if previous, found := patterns[patternKey]; found {
+			rocksChanges := chamber.rocksCount - previous.rocksCount
+			highestPointChanges := chamber.height - previous.height
+			cycles := (totRocks - chamber.rocksCount) / rocksChanges
+			chamber.additionalHeight += cycles * highestPointChanges
+			chamber.rocksCount += cycles * rocksChanges
+			skipped = true
+			continue
 		}
-		jp.Next()
-		//  move down
-		err := chamber.MoveDown()
-		if err != nil {
-			// add rock to lines
-			allPoints := chamber.currentRock.AllPoints()
-			for _, point := range allPoints {
-				chamber.linesBottomUp[point] = chamber.currentRock.char
-			}
-			// add rock to list
-			chamber.rocksCount++
-			if chamber.currentRock.topLeftPoint.y > chamber.height {
-				chamber.height = chamber.currentRock.topLeftPoint.y
-			}
-			chamber.moves = append(chamber.moves, Move{x: chamber.currentRock.topLeftPoint.x, rockChar: chamber.currentRock.char})
-			chamber.currentRock = nil
-			// add 8 empty rows
-			firstEmptyY := chamber.height + 1
-			for y := firstEmptyY; y < firstEmptyY+8; y++ {
-				for x := 0; x < 7; x++ {
-					chamber.linesBottomUp[Point{x: int64(x), y: int64(y)}] = EMPTY_RUNE
-				}
-			}
-
-			patternKey := chamber.BuildPatternKey(jp)
+		patterns[patternKey] = Pattern{rocksCount: chamber.rocksCount, height: chamber.height}
+	}
+}
 
-			if _, found := patterns[patternKey]; found {
-				previous := patterns[patternKey]
-				rocksChanges := chamber.rocksCount - previous.rocksCount
-				highestPointChanges := chamber.height - previous.height
-				cycles := (totRocks-previous.rocksCount)/rocksChanges - 1
-				chamber.additionalHeight += cycles * highestPointChanges
-				chamber.rocksCount += cycles * rocksChanges
-				continue
+// top rows of the chamber, drawn like in the puzzle
+func (c *Chamber) Render(numberOfLines int64) []string {
+	ret := []string{}
+	bottom := c.height - numberOfLines
+	if bottom < c.floor {
+		bottom = c.floor
+	}
+	for y := c.height - 1; y >= bottom; y-- {
+		line := ""
+		for x := 0; x < c.config.Width; x++ {
+			if c.row(y)&(1<<x) != 0 {
+				line += "#"
+			} else {
+				line += "."
 			}
-			patterns[patternKey] = Pattern{rocksCount: chamber.rocksCount, height: chamber.height}
 		}
+		ret = append(ret, fmt.Sprintf("|%s|", line))
+	}
+	if bottom == 0 {
+		ret = append(ret, fmt.Sprintf("+%s+", strings.Repeat("-", c.config.Width)))
 	}
+	return ret
 }
 
-func (c *Chamber) BuildPatternKey(jp JetPattern) string {
-	return fmt.Sprintf("%d|%d|%s",
-		jp.currentIndex,
-		c.currentRockIndex,
-		strings.Join(c.Print(5), "|"))
+func (c *Chamber) Print(numberOfLines int64) {
+	for _, line := range c.Render(numberOfLines) {
+		fmt.Println(line)
+	}
 }
@@ -15,3 +15,81 @@ func TestPart1(t *testing.T) {
 func TestPart2(t *testing.T) {
 	assert.Equal(t, "1514285714288", part2(data), "Failed testing part 2")
 }
+
+func TestRender(t *testing.T) {
+	jp := ParseJetPattern(data)
+	chamber, err := NewChamber(DefaultConfig())
+	assert.Nil(t, err)
+	chamber.Play(jp, 3)
+	assert.Equal(t, []string{
+		"|..#....|",
+		"|..#....|",
+		"|####...|",
+		"|..###..|",
+		"|...#...|",
+		"|..####.|",
+		"+-------+",
+	}, chamber.Render(10))
+	assert.Equal(t, []int64{2, 2, 0, 2, 3, 5, 6}, chamber.SurfaceProfile())
+}
+
+func TestConfig(t *testing.T) {
+	_, err := ParseShapes("##\n#x")
+	assert.NotNil(t, err)
+
+	shapes, err := ParseShapes("###\n\n#\n#")
+	assert.Nil(t, err)
+	_, err = NewChamber(Config{Shapes: shapes, Width: 4, SpawnX: 2, SpawnY: 3})
+	assert.NotNil(t, err)
+	_, err = NewChamber(Config{Shapes: shapes, Width: 65, SpawnX: 0, SpawnY: 3})
+	assert.NotNil(t, err)
+}
+
+func TestPlayVariants(t *testing.T) {
+	shapes, err := ParseShapes("###\n\n.#\n##\n\n#\n#")
+	assert.Nil(t, err)
+	configs := []Config{
+		DefaultConfig(),
+		{Shapes: shapes, Width: 5, SpawnX: 1, SpawnY: 2},
+		{Shapes: DefaultConfig().Shapes, Width: 11, SpawnX: 4, SpawnY: 5},
+	}
+	for _, config := range configs {
+		// extrapolated cycles match the rock by rock simulation
+		jp := ParseJetPattern(data)
+		simulated, err := NewChamber(config)
+		assert.Nil(t, err)
+		for i := 0; i < 10000; i++ {
+			simulated.DropRock(&jp)
+		}
+		extrapolated, err := NewChamber(config)
+		assert.Nil(t, err)
+		extrapolated.Play(ParseJetPattern(data), 10000)
+		assert.Equal(t, simulated.Height(), extrapolated.Height())
+	}
+}
+
+func TestTrimming(t *testing.T) {
+	// one column wide rocks fall through narrow gaps
+	well, err := ParseShapes("####\n\n#")
+	assert.Nil(t, err)
+	for _, config := range []Config{
+		DefaultConfig(),
+		{Shapes: well, Width: 5, SpawnX: 0, SpawnY: 3},
+	} {
+		trimmed, err := NewChamber(config)
+		assert.Nil(t, err)
+		trimmed.maxLines, trimmed.trimAt = 5, 10
+		kept, err := NewChamber(config)
+		assert.Nil(t, err)
+		kept.maxLines, kept.trimAt = 1<<30, 1<<30
+
+		trimmedJets, keptJets := ParseJetPattern(data), ParseJetPattern(data)
+		for i := 0; i < 3000; i++ {
+			trimmed.DropRock(&trimmedJets)
+			kept.DropRock(&keptJets)
+			assert.Equal(t, kept.Height(), trimmed.Height(), "Failed rock %d", i+1)
+		}
+		assert.Equal(t, kept.Render(5), trimmed.Render(5))
+		assert.Greater(t, trimmed.floor, int64(0))
+	}
+}
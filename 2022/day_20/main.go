@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"flag"
 	"fmt"
+	"math/rand"
 	"strconv"
 	"strings"
 )
@@ -26,132 +27,108 @@ func main() {
 }
 
 func part1(data string) string {
-	mixingFile := NewMixingFile(data)
-	mixingFile.Mix()
-	res := 0
-	for _, item := range mixingFile.GetGrooveItems() {
-		res += item.value
-	}
-	return strconv.Itoa(res)
+	return strconv.Itoa(Decrypt(NewMixingFile(data), SIMPLE_DECRYPTION))
 }
 
 func part2(data string) string {
-	mixingFile := NewMixingFile(data)
-	for _, item := range mixingFile.numbers {
-		item.value *= 811589153
-	}
-	for i := 0; i < 10; i++ {
+	return strconv.Itoa(Decrypt(NewMixingFile(data), FULL_DECRYPTION))
+}
+
+type Decryption struct {
+	Key          int
+	Rounds       int
+	GroveOffsets []int
+}
+
+var SIMPLE_DECRYPTION = Decryption{
+	Key:          1,
+	Rounds:       1,
+	GroveOffsets: []int{1000, 2000, 3000},
+}
+
+var FULL_DECRYPTION = Decryption{
+	Key:          811589153,
+	Rounds:       10,
+	GroveOffsets: []int{1000, 2000, 3000},
+}
+
+// sum of the grove coordinates after mixing the file
+func Decrypt(mixingFile *MixingFile, decryption Decryption) int {
+	mixingFile.ApplyKey(decryption.Key)
+	for i := 0; i < decryption.Rounds; i++ {
 		mixingFile.Mix()
 	}
 	res := 0
-	for _, item := range mixingFile.GetGrooveItems() {
+	for _, item := range mixingFile.GetGroveItems(decryption.GroveOffsets) {
 		res += item.value
 	}
-	return strconv.Itoa(res)
-}
-
-type Item struct {
-	value         int
-	originalIndex int
-	currentIndex  int
-}
-
-type MixingFile struct {
-	numbers   []*Item
-	originals []*Item
-	zero      *Item
-	length    int
+	return res
 }
 
-func NewMixingFile(data string) MixingFile {
-	mixingFile := MixingFile{
-		numbers:   []*Item{},
+func NewMixingFile(data string) *MixingFile {
+	mixingFile := &MixingFile{
 		originals: []*Item{},
+		random:    rand.New(rand.NewSource(1)),
 	}
-	for i, line := range strings.Split(data, "\n") {
+	for _, line := range strings.Split(data, "\n") {
 		num, err := strconv.Atoi(line)
 		if err != nil {
 			panic(err)
 		}
-		item := &Item{value: num, originalIndex: i, currentIndex: i}
-		mixingFile.numbers = append(mixingFile.numbers, item)
-		mixingFile.originals = append(mixingFile.numbers, item)
+		item := mixingFile.newItem(num)
+		mixingFile.originals = append(mixingFile.originals, item)
+		mixingFile.root = merge(mixingFile.root, item)
 		if num == 0 {
 			mixingFile.zero = item
 		}
 	}
-	mixingFile.length = len(mixingFile.numbers)
 	return mixingFile
 }
 
-func (mf *MixingFile) Mix() {
-	for i := 0; i < mf.length; i++ {
-		// mix item at original index i
-		// let's see where the item is now
-		var currentItem = mf.originals[i]
-
-		moves := currentItem.value % (mf.length - 1)
-
-		if moves == 0 {
-			continue
-		}
+func (mf *MixingFile) ApplyKey(key int) {
+	for _, item := range mf.originals {
+		item.value *= key
+	}
+}
 
-		newIndex := currentItem.currentIndex + moves
-		if newIndex <= 0 {
-			newIndex += mf.length - 1
-		}
-		if newIndex > mf.length-1 {
-			newIndex -= mf.length - 1
-		}
-		// update indexes
-		if newIndex > currentItem.currentIndex {
-			// element moved to the right
-			// all elements between currentIndex + 1 and newIndex included move to the left
-			for j := currentItem.currentIndex + 1; j < newIndex+1; j++ {
-				mf.numbers[j].currentIndex -= 1
-				if mf.numbers[j].currentIndex < 0 {
-					mf.numbers[j].currentIndex += mf.length
-				}
-			}
-		} else {
-			// element moved to the left
-			for j := newIndex; j < currentItem.currentIndex; j++ {
-				mf.numbers[j].currentIndex += 1
-				if mf.numbers[j].currentIndex > mf.length-1 {
-					mf.numbers[j].currentIndex -= mf.length
-				}
-			}
+// move every item, in original order, by its value around the circle
+func (mf *MixingFile) Mix() {
+	length := len(mf.originals)
+	if length < 2 {
+		return
+	}
+	for _, item := range mf.originals {
+		index := item.Index()
+		newIndex := (index + item.value) % (length - 1)
+		if newIndex < 0 {
+			newIndex += length - 1
 		}
-
-		// update numbers
-		newNumbers := []*Item{}
-		if newIndex > currentItem.currentIndex {
-			if currentItem.currentIndex > 0 {
-				newNumbers = append(newNumbers, mf.numbers[0:currentItem.currentIndex]...)
-			}
-			newNumbers = append(newNumbers, mf.numbers[currentItem.currentIndex+1:newIndex+1]...)
-			newNumbers = append(newNumbers, currentItem)
-			newNumbers = append(newNumbers, mf.numbers[newIndex+1:mf.length]...)
-
-		} else {
-			if newIndex > 0 {
-				newNumbers = append(newNumbers, mf.numbers[0:newIndex]...)
-			}
-			newNumbers = append(newNumbers, currentItem)
-			newNumbers = append(newNumbers, mf.numbers[newIndex:currentItem.currentIndex]...)
-			newNumbers = append(newNumbers, mf.numbers[currentItem.currentIndex+1:mf.length]...)
+		if newIndex == index {
+			continue
 		}
-		mf.numbers = newNumbers
+		left, right := split(mf.root, index)
+		_, right = split(right, 1)
+		mf.root = merge(left, right)
+		left, right = split(mf.root, newIndex)
+		mf.root = merge(merge(left, item), right)
+	}
+}
 
-		currentItem.currentIndex = newIndex
+// items at the given offsets after zero, wrapping around
+func (mf *MixingFile) GetGroveItems(offsets []int) []*Item {
+	groves := []*Item{}
+	zeroIndex := mf.zero.Index()
+	for _, offset := range offsets {
+		groves = append(groves, mf.root.at((zeroIndex+offset)%len(mf.originals)))
 	}
+	return groves
 }
 
-func (mf *MixingFile) GetGrooveItems() []*Item {
-	grooves := []*Item{}
-	zeroIndex := mf.zero.currentIndex
-	grooves = append(grooves, mf.numbers[(zeroIndex+1000)%mf.length])
-	grooves = append(grooves, mf.numbers[(zeroIndex+2000)%mf.length])
-	grooves = append(grooves, mf.numbers[(zeroIndex+3000)%mf.length])
-	return grooves
+// values in their current order
+func (mf *MixingFile) Values() []int {
+	values := []int{}
+	for i := 0; i < len(mf.originals); i++ {
+		values = append(values, mf.root.at(i).value)
+	}
+	return values
 }
@@ -21,3 +21,18 @@ func TestPart1(t *testing.T) {
 func TestPart2(t *testing.T) {
 	assert.Equal(t, "1623178306", part2(data), "Failed testing part 2")
 }
+
+func TestMix(t *testing.T) {
+	mixingFile := NewMixingFile(data)
+	mixingFile.Mix()
+	values := mixingFile.Values()
+	zeroIndex := mixingFile.zero.Index()
+	// circle 1, 2, -3, 4, 0, 3, -2 read from zero
+	rotated := append(values[zeroIndex:], values[:zeroIndex]...)
+	assert.Equal(t, []int{0, 3, -2, 1, 2, -3, 4}, rotated)
+}
+
+func TestDecrypt(t *testing.T) {
+	decryption := Decryption{Key: 811589153, Rounds: 1, GroveOffsets: []int{1000}}
+	assert.Equal(t, 811589153, Decrypt(NewMixingFile(data), decryption))
+}
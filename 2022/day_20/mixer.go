@@ -0,0 +1,119 @@
+package main
+
+import "math/rand"
+
+// Item is a number of the file and a node of the implicit treap keeping the
+// mixing order: its position is the count of items on its left in the tree,
+// so moving an item is a removal and an insertion in O(log n)
+type Item struct {
+	value int
+
+	priority int64
+	size     int
+	left     *Item
+	right    *Item
+	parent   *Item
+}
+
+type MixingFile struct {
+	// items in their original order
+	originals []*Item
+	zero      *Item
+	root      *Item
+	random    *rand.Rand
+}
+
+func (mf *MixingFile) newItem(value int) *Item {
+	return &Item{
+		value:    value,
+		priority: mf.random.Int63(),
+		size:     1,
+	}
+}
+
+func (item *Item) getSize() int {
+	if item == nil {
+		return 0
+	}
+	return item.size
+}
+
+func (item *Item) update() {
+	item.size = 1 + item.left.getSize() + item.right.getSize()
+	if item.left != nil {
+		item.left.parent = item
+	}
+	if item.right != nil {
+		item.right.parent = item
+	}
+}
+
+// current position of the item in the file
+func (item *Item) Index() int {
+	index := item.left.getSize()
+	for node := item; node.parent != nil; node = node.parent {
+		if node.parent.right == node {
+			index += node.parent.left.getSize() + 1
+		}
+	}
+	return index
+}
+
+// item at the given position of the tree rooted in this item
+func (item *Item) at(index int) *Item {
+	node := item
+	for {
+		leftSize := node.left.getSize()
+		switch {
+		case index < leftSize:
+			node = node.left
+		case index == leftSize:
+			return node
+		default:
+			index -= leftSize + 1
+			node = node.right
+		}
+	}
+}
+
+// split the tree into its first count items and the rest
+func split(root *Item, count int) (*Item, *Item) {
+	if root == nil {
+		return nil, nil
+	}
+	root.parent = nil
+	if root.left.getSize() >= count {
+		left, right := split(root.left, count)
+		root.left = right
+		root.update()
+		return left, root
+	}
+	left, right := split(root.right, count-root.left.getSize()-1)
+	root.right = left
+	root.update()
+	return root, right
+}
+
+// concatenate two trees, keeping the heap order of priorities
+func merge(left *Item, right *Item) *Item {
+	if left == nil {
+		if right != nil {
+			right.parent = nil
+		}
+		return right
+	}
+	if right == nil {
+		left.parent = nil
+		return left
+	}
+	if left.priority > right.priority {
+		left.right = merge(left.right, right)
+		left.update()
+		left.parent = nil
+		return left
+	}
+	right.left = merge(left, right.left)
+	right.update()
+	right.parent = nil
+	return right
+}
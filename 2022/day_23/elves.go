@@ -0,0 +1,126 @@
+package main
+
+import (
+	"math/bits"
+
+	"github.com/pducolin/advent-of-code/2022/common"
+)
+
+// ElfSet stores the positions of the elves
+type ElfSet interface {
+	Has(point common.Point) bool
+	Add(point common.Point)
+	Len() int
+	Points() []common.Point
+	// empty set of the same kind, able to hold any point between min and max
+	Empty(min, max common.Point) ElfSet
+}
+
+type Backend int
+
+const (
+	// sparse, grows without limits
+	MAP_BACKEND Backend = iota
+	// one bit per tile of the bounding box, resized every round
+	DENSE_BACKEND
+)
+
+func newElfSet(backend Backend, min, max common.Point) ElfSet {
+	if backend == DENSE_BACKEND {
+		return newDenseElves(min, max)
+	}
+	return mapElves{}
+}
+
+type mapElves map[common.Point]struct{}
+
+func (elves mapElves) Has(point common.Point) bool {
+	_, found := elves[point]
+	return found
+}
+
+func (elves mapElves) Add(point common.Point) {
+	elves[point] = struct{}{}
+}
+
+func (elves mapElves) Len() int {
+	return len(elves)
+}
+
+func (elves mapElves) Points() []common.Point {
+	points := make([]common.Point, 0, len(elves))
+	for point := range elves {
+		points = append(points, point)
+	}
+	return points
+}
+
+func (elves mapElves) Empty(min, max common.Point) ElfSet {
+	return mapElves{}
+}
+
+// denseElves is a bitset over the rectangle between min and max, row by row
+type denseElves struct {
+	min           common.Point
+	width, height int
+	words         []uint64
+	count         int
+}
+
+func newDenseElves(min, max common.Point) *denseElves {
+	width, height := max.X-min.X+1, max.Y-min.Y+1
+	return &denseElves{
+		min:    min,
+		width:  width,
+		height: height,
+		words:  make([]uint64, (width*height+63)/64),
+	}
+}
+
+// bit index of the point, -1 outside of the rectangle
+func (elves *denseElves) index(point common.Point) int {
+	x, y := point.X-elves.min.X, point.Y-elves.min.Y
+	if x < 0 || x >= elves.width || y < 0 || y >= elves.height {
+		return -1
+	}
+	return y*elves.width + x
+}
+
+func (elves *denseElves) Has(point common.Point) bool {
+	index := elves.index(point)
+	return index >= 0 && elves.words[index/64]&(1<<(index%64)) != 0
+}
+
+func (elves *denseElves) Add(point common.Point) {
+	index := elves.index(point)
+	if index < 0 {
+		panic("elf out of the dense grid")
+	}
+	if elves.words[index/64]&(1<<(index%64)) == 0 {
+		elves.words[index/64] |= 1 << (index % 64)
+		elves.count++
+	}
+}
+
+func (elves *denseElves) Len() int {
+	return elves.count
+}
+
+func (elves *denseElves) Points() []common.Point {
+	points := make([]common.Point, 0, elves.count)
+	for w, word := range elves.words {
+		for word != 0 {
+			index := w*64 + bits.TrailingZeros64(word)
+			points = append(points, common.Point{
+				X: elves.min.X + index%elves.width,
+				Y: elves.min.Y + index/elves.width,
+			})
+			word &= word - 1
+		}
+	}
+	return points
+}
+
+func (elves *denseElves) Empty(min, max common.Point) ElfSet {
+	return newDenseElves(min, max)
+}
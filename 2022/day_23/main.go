@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"flag"
 	"fmt"
+	"image/color"
 	"math"
 	"strconv"
 	"strings"
@@ -16,11 +17,28 @@ var inputData string
 
 func main() {
 	var part int
+	var render string
 	flag.IntVar(&part, "part", 1, "part 1 or 2")
+	flag.StringVar(&render, "render", "", "write the elves spreading to this GIF, or PNG for the final state")
 	flag.Parse()
 
 	fmt.Println("Running part", part)
 
+	if render != "" {
+		rounds := 10
+		if part == 2 {
+			var err error
+			rounds, err = strconv.Atoi(part2(inputData))
+			if err != nil {
+				panic(err)
+			}
+		}
+		if err := animate(inputData, rounds, render); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	if part == 1 {
 		fmt.Println(part1(inputData))
 	} else {
@@ -38,23 +56,56 @@ func part1(data string) string {
 
 func part2(data string) string {
 	grid := NewGrid(data)
-	previousState := grid.ToString()
-	iterationCount := 0
-	for {
-		grid.Iterate()
+	iterationCount := 1
+	for grid.Iterate() > 0 {
 		iterationCount++
-		currentState := grid.ToString()
-		if strings.Join(currentState, "\n") == strings.Join(previousState, "\n") {
-			break
-		}
-		previousState = currentState
 	}
 	return strconv.Itoa(iterationCount)
 }
 
+var PALETTE = common.Palette{
+	Background: color.Black,
+	Colors: map[rune]color.Color{
+		'#': color.RGBA{R: 0x2e, G: 0xcc, B: 0x71, A: 0xff},
+	},
+}
+
+// record the elves after each round, within the area they cover over all
+// rounds so that frames line up
+func animate(data string, rounds int, path string) error {
+	grid := NewGrid(data)
+	minX, maxX, minY, maxY := grid.getLimits()
+	for i := 0; i < rounds; i++ {
+		grid.Iterate()
+		roundMinX, roundMaxX, roundMinY, roundMaxY := grid.getLimits()
+		if roundMinX < minX {
+			minX = roundMinX
+		}
+		if roundMaxX > maxX {
+			maxX = roundMaxX
+		}
+		if roundMinY < minY {
+			minY = roundMinY
+		}
+		if roundMaxY > maxY {
+			maxY = roundMaxY
+		}
+	}
+
+	grid = NewGrid(data)
+	animation := common.NewAnimation(PALETTE, 4, 5)
+	animation.AddFrame(grid.Render(minX, maxX, minY, maxY))
+	for i := 0; i < rounds; i++ {
+		grid.Iterate()
+		animation.AddFrame(grid.Render(minX, maxX, minY, maxY))
+	}
+	return animation.WriteFile(path)
+}
+
 type Grid struct {
-	elves     map[common.Point]struct{}
-	moveIndex int
+	elves  ElfSet
+	config Config
+	round  int
 }
 
 type Direction int
@@ -70,119 +121,157 @@ const (
 	NW
 )
 
-var (
-	DIRECTIONS = []Direction{N, NE, E, SE, S, SW, W, NW}
-	MOVES      = []Direction{N, S, W, E}
-)
+var DIRECTIONS = []Direction{N, NE, E, SE, S, SW, W, NW}
 
-func NewGrid(data string) Grid {
-	grid := Grid{
-		elves: map[common.Point]struct{}{},
+// Rule moves an elf one tile towards Move when none of the Check neighbours
+// is taken
+type Rule struct {
+	Move  Direction
+	Check []Direction
+}
+
+// Rotation gives the index of the first rule tried at a round, starting from 0
+type Rotation func(round int, rules int) int
+
+// the first rule of a round becomes the last of the next one
+func RotateEachRound(round int, rules int) int {
+	return round % rules
+}
+
+func FixedOrder(round int, rules int) int {
+	return 0
+}
+
+type Config struct {
+	// tried in order, the first rule that applies moves the elf
+	Rules []Rule
+	// elves with none of these neighbours taken do not move
+	Neighbours []Direction
+	Rotation   Rotation
+	Backend    Backend
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Rules: []Rule{
+			{Move: N, Check: []Direction{NE, N, NW}},
+			{Move: S, Check: []Direction{SE, S, SW}},
+			{Move: W, Check: []Direction{W, SW, NW}},
+			{Move: E, Check: []Direction{E, SE, NE}},
+		},
+		Neighbours: DIRECTIONS,
+		Rotation:   RotateEachRound,
+		Backend:    DENSE_BACKEND,
 	}
-	for y, line := range strings.Split(data, "\n") {
+}
+
+func NewGrid(data string) Grid {
+	return NewGridWith(data, DefaultConfig())
+}
+
+func NewGridWith(data string, config Config) Grid {
+	lines := strings.Split(data, "\n")
+	elves := newElfSet(config.Backend, common.Point{}, common.Point{X: len(lines[0]) - 1, Y: len(lines) - 1})
+	for y, line := range lines {
 		for x, r := range line {
 			if r == '#' {
-				point := common.Point{X: x, Y: y}
-				grid.elves[point] = struct{}{}
+				elves.Add(common.Point{X: x, Y: y})
 			}
 		}
 	}
-	return grid
+	return Grid{
+		elves:  elves,
+		config: config,
+	}
+}
+
+type proposal struct {
+	from, to common.Point
 }
 
-func (grid *Grid) Iterate() {
-	proposedPointCountByPoint := map[common.Point]int{}
-	movingElvesByOrigin := map[common.Point]common.Point{}
-	stillElves := []common.Point{}
+// Iterate plays a round and returns how many elves moved
+func (grid *Grid) Iterate() (moved int) {
+	if grid.elves.Len() == 0 {
+		grid.round++
+		return 0
+	}
+	minX, maxX, minY, maxY := grid.getLimits()
+	// elves move at most one tile away
+	min := common.Point{X: minX - 1, Y: minY - 1}
+	max := common.Point{X: maxX + 1, Y: maxY + 1}
 
 	// first half
-	for elfPosition := range grid.elves {
-		if !grid.HasNeighboursAround(elfPosition) {
-			stillElves = append(stillElves, elfPosition)
+	newElves := grid.elves.Empty(min, max)
+	proposed := grid.elves.Empty(min, max)
+	contested := grid.elves.Empty(min, max)
+	proposals := []proposal{}
+	firstRule := grid.config.Rotation(grid.round, len(grid.config.Rules))
+	for _, elfPosition := range grid.elves.Points() {
+		newElfPosition, found := grid.propose(elfPosition, firstRule)
+		if !found {
+			newElves.Add(elfPosition)
 			continue
 		}
-
-		canMove := false
-		for i := 0; i < len(MOVES); i++ {
-			moveIndex := (grid.moveIndex + i) % len(MOVES)
-			if grid.canMove(elfPosition, MOVES[moveIndex]) {
-				newElfPosition := GetNeighbour(elfPosition, MOVES[moveIndex])
-				movingElvesByOrigin[elfPosition] = newElfPosition
-				if _, found := proposedPointCountByPoint[newElfPosition]; !found {
-					proposedPointCountByPoint[newElfPosition] = 0
-				}
-				proposedPointCountByPoint[newElfPosition]++
-				canMove = true
-				break
-			}
-		}
-		if !canMove {
-			stillElves = append(stillElves, elfPosition)
+		if proposed.Has(newElfPosition) {
+			contested.Add(newElfPosition)
 		}
+		proposed.Add(newElfPosition)
+		proposals = append(proposals, proposal{from: elfPosition, to: newElfPosition})
 	}
 
 	// second half
-	newElves := map[common.Point]struct{}{}
-	for oldElfPosition, newElfPosition := range movingElvesByOrigin {
-		if proposedPointCountByPoint[newElfPosition] > 1 {
-			stillElves = append(stillElves, oldElfPosition)
+	for _, proposal := range proposals {
+		if contested.Has(proposal.to) {
+			newElves.Add(proposal.from)
 			continue
 		}
-		newElves[newElfPosition] = struct{}{}
+		newElves.Add(proposal.to)
+		moved++
 	}
 
-	if len(newElves)+len(stillElves) != len(grid.elves) {
+	if newElves.Len() != grid.elves.Len() {
 		panic("elves were lost")
 	}
 
-	for _, elf := range stillElves {
-		if _, found := newElves[elf]; found {
-			panic("illegal elf move")
-		}
-		newElves[elf] = struct{}{}
-	}
-
-	// update elves
-	grid.elves = map[common.Point]struct{}{}
 	grid.elves = newElves
-
-	// last part, update move index
-	grid.moveIndex = (grid.moveIndex + 1) % len(MOVES)
+	grid.round++
+	return moved
 }
 
-func (grid *Grid) canMove(point common.Point, direction Direction) bool {
-	var directionsToCheck []Direction
-	switch direction {
-	case N:
-		directionsToCheck = []Direction{NE, N, NW}
-	case S:
-		directionsToCheck = []Direction{SE, S, SW}
-	case W:
-		directionsToCheck = []Direction{W, SW, NW}
-	case E:
-		directionsToCheck = []Direction{E, SE, NE}
-	default:
-		panic(fmt.Errorf("invalid direction to check %#v", direction))
+// position the elf proposes to move to, trying rules from the given index
+func (grid *Grid) propose(point common.Point, firstRule int) (common.Point, bool) {
+	if !grid.hasAny(point, grid.config.Neighbours) {
+		return point, false
 	}
-
-	for _, d := range directionsToCheck {
-		point := GetNeighbour(point, d)
-		if _, found := grid.elves[point]; found {
-			return false
+	rules := grid.config.Rules
+	for i := 0; i < len(rules); i++ {
+		rule := rules[(firstRule+i)%len(rules)]
+		if grid.hasAny(point, rule.Check) {
+			continue
+		}
+		// a rule that does not check its own move could step onto an elf
+		// staying put, the elf stays instead
+		target := GetNeighbour(point, rule.Move)
+		if grid.elves.Has(target) {
+			return point, false
 		}
+		return target, true
 	}
-	return true
+	return point, false
 }
 
-func (grid *Grid) HasNeighboursAround(point common.Point) bool {
-	countNeighbours := 0
-	for _, direction := range DIRECTIONS {
-		neighbour := GetNeighbour(point, direction)
-		if _, found := grid.elves[neighbour]; found {
-			countNeighbours++
+// whether any of the neighbours in the given directions is taken
+func (grid *Grid) hasAny(point common.Point, directions []Direction) bool {
+	for _, direction := range directions {
+		if grid.elves.Has(GetNeighbour(point, direction)) {
+			return true
 		}
 	}
-	return countNeighbours > 0
+	return false
+}
+
+func (grid *Grid) HasNeighboursAround(point common.Point) bool {
+	return grid.hasAny(point, DIRECTIONS)
 }
 
 func GetNeighbour(point common.Point, direction Direction) common.Point {
@@ -207,13 +296,17 @@ func GetNeighbour(point common.Point, direction Direction) common.Point {
 	panic(fmt.Errorf("unknown direction %#v", direction))
 }
 
+// bounding box of the elves, empty (max below min) without any elf
 func (grid *Grid) getLimits() (minX, maxX, minY, maxY int) {
+	if grid.elves.Len() == 0 {
+		return 0, -1, 0, -1
+	}
 	minX = math.MaxInt
 	maxX = math.MinInt
 	minY = math.MaxInt
 	maxY = math.MinInt
 
-	for position := range grid.elves {
+	for _, position := range grid.elves.Points() {
 		if position.X < minX {
 			minX = position.X
 		}
@@ -235,17 +328,20 @@ func (grid *Grid) CountEmptyTiles() int {
 	gridWidth := maxX - minX + 1
 	gridHeight := maxY - minY + 1
 
-	return gridWidth*gridHeight - len(grid.elves)
+	return gridWidth*gridHeight - grid.elves.Len()
 }
 
 func (grid *Grid) ToString() []string {
 	minX, maxX, minY, maxY := grid.getLimits()
+	return grid.Render(minX, maxX, minY, maxY)
+}
 
+func (grid *Grid) Render(minX, maxX, minY, maxY int) []string {
 	lines := []string{}
 	for y := minY; y <= maxY; y++ {
 		line := ""
 		for x := minX; x <= maxX; x++ {
-			if _, found := grid.elves[common.Point{X: x, Y: y}]; found {
+			if grid.elves.Has(common.Point{X: x, Y: y}) {
 				line += "#"
 				continue
 			}
@@ -54,9 +54,9 @@ const round1 = `.....#...
 
 func TestPart1(t *testing.T) {
 	grid := NewGrid(data)
-	initialElves := len(grid.elves)
+	initialElves := grid.elves.Len()
 	assert.Equal(t, 22, initialElves, "Failed parsing grid")
-	assert.Equal(t, initialElves, len(grid.elves), "Elves count changed after iteration")
+	assert.Equal(t, initialElves, grid.elves.Len(), "Elves count changed after iteration")
 	expectedLines := strings.Split(round1, "\n")
 	grid.Iterate()
 	assert.Equal(t, expectedLines, grid.ToString(), "Failed iteration 1")
@@ -66,3 +66,66 @@ func TestPart1(t *testing.T) {
 func TestPart2(t *testing.T) {
 	assert.Equal(t, "20", part2(data), "Failed testing part 2")
 }
+
+func TestMovedCount(t *testing.T) {
+	grid := NewGrid(smallerData)
+	// two elves propose the same tile and stay
+	assert.Equal(t, 3, grid.Iterate())
+	grid.Iterate()
+	grid.Iterate()
+	assert.Equal(t, 0, grid.Iterate())
+}
+
+func TestMapBackend(t *testing.T) {
+	// the default dense backend and the map one play the same rounds
+	dense := NewGrid(data)
+	config := DefaultConfig()
+	config.Backend = MAP_BACKEND
+	sparse := NewGridWith(data, config)
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, sparse.Iterate(), dense.Iterate(), "Failed round %d", i+1)
+		assert.Equal(t, sparse.ToString(), dense.ToString(), "Failed round %d", i+1)
+	}
+	assert.Equal(t, 0, dense.Iterate())
+}
+
+func TestCustomRules(t *testing.T) {
+	// only moving north, without rotation, when there is an elf above or below
+	config := DefaultConfig()
+	config.Rules = []Rule{{Move: N, Check: []Direction{N}}}
+	config.Neighbours = []Direction{N, S}
+	config.Rotation = FixedOrder
+	grid := NewGridWith("#\n#\n#", config)
+	assert.Equal(t, 1, grid.Iterate())
+	assert.Equal(t, []string{"#", ".", "#", "#"}, grid.ToString())
+	assert.Equal(t, 1, grid.Iterate())
+	assert.Equal(t, []string{"#", "#", ".", "#"}, grid.ToString())
+	assert.Equal(t, 1, grid.Iterate())
+	assert.Equal(t, 0, grid.Iterate())
+	assert.Equal(t, []string{"#", ".", "#", ".", "#"}, grid.ToString())
+}
+
+func TestRuleOntoStayingElf(t *testing.T) {
+	// moving south without checking south would step onto the elf below,
+	// which has no rule to follow and stays
+	config := DefaultConfig()
+	config.Rules = []Rule{{Move: S, Check: []Direction{N}}}
+	config.Rotation = FixedOrder
+	for _, backend := range []Backend{DENSE_BACKEND, MAP_BACKEND} {
+		config.Backend = backend
+		grid := NewGridWith("#\n#", config)
+		assert.Equal(t, 0, grid.Iterate())
+		assert.Equal(t, []string{"#", "#"}, grid.ToString())
+	}
+}
+
+func TestNoElves(t *testing.T) {
+	for _, backend := range []Backend{DENSE_BACKEND, MAP_BACKEND} {
+		config := DefaultConfig()
+		config.Backend = backend
+		grid := NewGridWith("...\n...", config)
+		assert.Equal(t, 0, grid.Iterate())
+		assert.Equal(t, 0, grid.CountEmptyTiles())
+		assert.Empty(t, grid.ToString())
+	}
+}
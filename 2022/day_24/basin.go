@@ -0,0 +1,197 @@
+package main
+
+import (
+	"github.com/pducolin/advent-of-code/2022/common"
+)
+
+// set of small non-negative integers
+type bitset []uint64
+
+func newBitset(size int) bitset {
+	return make(bitset, (size+63)/64)
+}
+
+func (set bitset) add(i int) {
+	set[i/64] |= 1 << (i % 64)
+}
+
+func (set bitset) has(i int) bool {
+	return set[i/64]&(1<<(i%64)) != 0
+}
+
+// Basin tells where blizzards are at any minute without simulating them:
+// blizzards only move along their row or column and wrap around the inner
+// area, so a blizzard moving right is at column (x + t) mod width at minute t
+// if it started at column x. The whole basin repeats every
+// lcm(width, height) minutes.
+type Basin struct {
+	// size of the grid, walls included
+	width, height int
+	period        int
+	// for each inner row, columns of blizzards moving left or right at minute 0
+	left, right []bitset
+	// for each inner column, rows of blizzards moving up or down at minute 0
+	up, down []bitset
+}
+
+func NewBasin(grid Grid) *Basin {
+	innerWidth, innerHeight := grid.width-2, grid.height-2
+	basin := &Basin{
+		width:  grid.width,
+		height: grid.height,
+		period: lcm(innerWidth, innerHeight),
+		left:   make([]bitset, innerHeight),
+		right:  make([]bitset, innerHeight),
+		up:     make([]bitset, innerWidth),
+		down:   make([]bitset, innerWidth),
+	}
+	for y := 0; y < innerHeight; y++ {
+		basin.left[y] = newBitset(innerWidth)
+		basin.right[y] = newBitset(innerWidth)
+	}
+	for x := 0; x < innerWidth; x++ {
+		basin.up[x] = newBitset(innerHeight)
+		basin.down[x] = newBitset(innerHeight)
+	}
+
+	for position, blizzards := range grid.blizzards {
+		x, y := position.X-1, position.Y-1
+		for _, blizzard := range blizzards {
+			switch blizzard.direction {
+			case LEFT:
+				basin.left[y].add(x)
+			case RIGHT:
+				basin.right[y].add(x)
+			case UP:
+				basin.up[x].add(y)
+			case DOWN:
+				basin.down[x].add(y)
+			}
+		}
+	}
+	return basin
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func lcm(a, b int) int {
+	return a / gcd(a, b) * b
+}
+
+// positive modulo
+func mod(a, b int) int {
+	return ((a % b) + b) % b
+}
+
+func (basin *Basin) StartPosition() common.Point {
+	return common.Point{X: 1, Y: 0}
+}
+
+func (basin *Basin) EndPosition() common.Point {
+	return common.Point{X: basin.width - 2, Y: basin.height - 1}
+}
+
+func (basin *Basin) IsWall(position common.Point) bool {
+	if position == basin.StartPosition() || position == basin.EndPosition() {
+		return false
+	}
+
+	return (position.X <= 0 || position.X >= basin.width-1 ||
+		position.Y <= 0 || position.Y >= basin.height-1)
+}
+
+// whether any blizzard is on the position at the given minute
+func (basin *Basin) HasBlizzard(position common.Point, minute int) bool {
+	innerWidth, innerHeight := basin.width-2, basin.height-2
+	x, y := position.X-1, position.Y-1
+	if x < 0 || x >= innerWidth || y < 0 || y >= innerHeight {
+		return false
+	}
+	minute %= basin.period
+	return basin.right[y].has(mod(x-minute, innerWidth)) ||
+		basin.left[y].has(mod(x+minute, innerWidth)) ||
+		basin.down[x].has(mod(y-minute, innerHeight)) ||
+		basin.up[x].has(mod(y+minute, innerHeight))
+}
+
+func (basin *Basin) IsFree(position common.Point, minute int) bool {
+	return !basin.IsWall(position) && !basin.HasBlizzard(position, minute)
+}
+
+// positions free at the given minute, one move away or staying still
+func (basin *Basin) GetMoves(position common.Point, minute int) []common.Point {
+	moves := []common.Point{}
+	for _, next := range []common.Point{
+		moveTo(position, DOWN),
+		moveTo(position, RIGHT),
+		position,
+		moveTo(position, UP),
+		moveTo(position, LEFT),
+	} {
+		if basin.IsFree(next, minute) {
+			moves = append(moves, next)
+		}
+	}
+	return moves
+}
+
+// reaching a position at two minutes with the same phase of the period leads
+// to the same future, shifted by whole periods
+type basinState struct {
+	position common.Point
+	phase    int
+}
+
+type searchNode struct {
+	position common.Point
+	minute   int
+	// minute plus the manhattan distance left, which never overestimates
+	estimate int
+	// position at the previous minute, the starting point for the first node
+	from common.Point
+}
+
+// min-heap of search nodes, by estimate
+type searchHeap []searchNode
+
+func (h searchHeap) Len() int           { return len(h) }
+func (h searchHeap) Less(i, j int) bool { return h[i].estimate < h[j].estimate }
+func (h searchHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *searchHeap) Push(x interface{}) {
+	*h = append(*h, x.(searchNode))
+}
+
+func (h *searchHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
+
+func manhattan(a, b common.Point) int {
+	return abs(a.X-b.X) + abs(a.Y-b.Y)
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// FindShortestPath returns the minute the target is reached at, leaving the
+// starting point at the given minute
+func (basin *Basin) FindShortestPath(startingPoint, targetPoint common.Point, startMinute int) (arrival int, err error) {
+	path, err := basin.findPath(startingPoint, targetPoint, startMinute, TripOptions{})
+	if err != nil {
+		return -1, err
+	}
+	return startMinute + len(path) - 1, nil
+}
@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"flag"
 	"fmt"
+	"image/color"
 	"strconv"
 	"strings"
 
@@ -15,11 +16,47 @@ var inputData string
 
 func main() {
 	var part int
+	var render string
+	var route bool
+	var noWaiting bool
 	flag.IntVar(&part, "part", 1, "part 1 or 2")
+	flag.StringVar(&render, "render", "", "write the blizzards moving during the trip to this GIF, or PNG for the final state")
+	flag.BoolVar(&route, "route", false, "print the trip minute by minute")
+	flag.BoolVar(&noWaiting, "no-wait", false, "plan the printed trip without waiting")
 	flag.Parse()
 
 	fmt.Println("Running part", part)
 
+	if route {
+		grid := NewGrid(inputData)
+		basin := NewBasin(grid)
+		waypoints := []common.Point{basin.StartPosition(), basin.EndPosition()}
+		if part == 2 {
+			waypoints = append(waypoints, basin.StartPosition(), basin.EndPosition())
+		}
+		trip, err := basin.PlanTrip(waypoints, 0, TripOptions{NoWaiting: noWaiting})
+		if err != nil {
+			panic(err)
+		}
+		fmt.Print(trip.Replay(grid))
+		return
+	}
+
+	if render != "" {
+		solve := part1
+		if part == 2 {
+			solve = part2
+		}
+		minutes, err := strconv.Atoi(solve(inputData))
+		if err != nil {
+			panic(err)
+		}
+		if err := animate(inputData, minutes, render); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	if part == 1 {
 		fmt.Println(part1(inputData))
 	} else {
@@ -28,50 +65,54 @@ func main() {
 }
 
 func part1(data string) string {
-	grid := NewGrid(data)
+	basin := NewBasin(NewGrid(data))
 
-	playerPosition := grid.StartPosition()
-
-	ret, grid, err := FindShortestPath(grid, playerPosition, grid.EndPosition())
+	arrival, err := basin.FindShortestPath(basin.StartPosition(), basin.EndPosition(), 0)
 	if err != nil {
 		panic(err)
 	}
-	return strconv.Itoa(ret)
+	return strconv.Itoa(arrival)
 }
 
 func part2(data string) string {
-	grid := NewGrid(data)
-
-	totTimeElapsed := 0
-
-	steps := []Step{
-		Step{
-			from: grid.StartPosition(),
-			to:   grid.EndPosition(),
-		},
-		Step{
-			from: grid.EndPosition(),
-			to:   grid.StartPosition(),
-		},
-		Step{
-			from: grid.StartPosition(),
-			to:   grid.EndPosition(),
-		},
-	}
-	for _, step := range steps {
-		ret, newGrid, err := FindShortestPath(grid, step.from, step.to)
-		if err != nil {
-			panic(err)
-		}
-		totTimeElapsed += ret
-		grid = newGrid
+	basin := NewBasin(NewGrid(data))
+
+	trip, err := basin.PlanTrip([]common.Point{
+		basin.StartPosition(),
+		basin.EndPosition(),
+		basin.StartPosition(),
+		basin.EndPosition(),
+	}, 0, TripOptions{})
+	if err != nil {
+		panic(err)
 	}
-	return strconv.Itoa(totTimeElapsed)
+	return strconv.Itoa(trip.Arrival())
 }
 
-type Step struct {
-	from common.Point
-	to   common.Point
+var PALETTE = common.Palette{
+	Background: color.Black,
+	Colors: map[rune]color.Color{
+		'#': color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff},
+		'<': color.RGBA{R: 0x9e, G: 0xd8, B: 0xf0, A: 0xff},
+		'>': color.RGBA{R: 0x9e, G: 0xd8, B: 0xf0, A: 0xff},
+		'^': color.RGBA{R: 0x9e, G: 0xd8, B: 0xf0, A: 0xff},
+		'v': color.RGBA{R: 0x9e, G: 0xd8, B: 0xf0, A: 0xff},
+		'2': color.RGBA{R: 0xd0, G: 0xee, B: 0xfa, A: 0xff},
+		'3': color.White,
+		'4': color.White,
+	},
+}
+
+// record the blizzards every minute
+func animate(data string, minutes int, path string) error {
+	grid := NewGrid(data)
+	animation := common.NewAnimation(PALETTE, 4, 10)
+	animation.AddFrame(grid.ToString())
+	for i := 0; i < minutes; i++ {
+		grid = grid.Iterate()
+		animation.AddFrame(grid.ToString())
+	}
+	return animation.WriteFile(path)
 }
 
 type Grid struct {
@@ -265,87 +306,6 @@ func (grid *Grid) iterateBlizzard(blizzard Blizzard) Blizzard {
 	return blizzard
 }
 
-type PlayerState struct {
-	player      common.Point
-	timeElapsed int
-}
-
-func (grid *Grid) GetMoves(position common.Point) []common.Point {
-	moves := []common.Point{}
-
-	nextPosition := moveTo(position, DOWN)
-	if grid.isValidPlayerPosition(nextPosition) {
-		moves = append(moves, nextPosition)
-	}
-	nextPosition = moveTo(position, RIGHT)
-	if grid.isValidPlayerPosition(nextPosition) {
-		moves = append(moves, nextPosition)
-	}
-	// stay still
-	if grid.isValidPlayerPosition(position) {
-		moves = append(moves, position)
-	}
-	// UP
-	nextPosition = moveTo(position, UP)
-	if grid.isValidPlayerPosition(nextPosition) {
-		moves = append(moves, nextPosition)
-	}
-	// LEFT
-	nextPosition = moveTo(position, LEFT)
-	if grid.isValidPlayerPosition(nextPosition) {
-		moves = append(moves, nextPosition)
-	}
-	return moves
-}
-
-func FindShortestPath(initialGrid Grid, startingPoint common.Point, targetPoint common.Point) (shortestPath int, finalGrid Grid, err error) {
-	visited := map[PlayerState]struct{}{}
-
-	// Inspired by @ValerieMauduit
-	// and her tip: "Blizzard move over time, they do not depend on our position"
-	// At a given moment in time, we have one and only one grid
-	// Time is the third dimension of the grid
-	gridByTime := []Grid{
-		initialGrid,
-	}
-
-	queue := common.NewQueue[PlayerState]()
-
-	queue.Push(PlayerState{
-		player:      startingPoint,
-		timeElapsed: 0,
-	})
-
-	for !queue.IsEmpty() {
-		currentState := queue.Pop()
-
-		if currentState.player == targetPoint {
-			return currentState.timeElapsed, gridByTime[currentState.timeElapsed], nil
-		}
-
-		if _, found := visited[currentState]; found {
-			continue
-		}
-
-		nextTime := currentState.timeElapsed + 1
-		// iterate grid to get next minute grid, if it does not exist
-		if len(gridByTime)-1 < nextTime {
-			gridByTime = append(gridByTime, gridByTime[currentState.timeElapsed].Iterate())
-		}
-		nextGrid := gridByTime[nextTime]
-
-		for _, move := range nextGrid.GetMoves(currentState.player) {
-			queue.Push(PlayerState{
-				player:      move,
-				timeElapsed: nextTime})
-		}
-
-		visited[currentState] = struct{}{}
-	}
-
-	return -1, initialGrid, fmt.Errorf("no path from %#v", startingPoint)
-}
-
 func (grid *Grid) isValidPlayerPosition(position common.Point) bool {
 	if grid.IsWall(position) {
 		return false
@@ -57,3 +57,91 @@ func TestPart1(t *testing.T) {
 func TestPart2(t *testing.T) {
 	assert.Equal(t, "54", part2(data), "Failed testing part 2")
 }
+
+func TestHasBlizzard(t *testing.T) {
+	grid := NewGrid(data)
+	basin := NewBasin(grid)
+	assert.Equal(t, 12, basin.period)
+	// analytic occupancy matches the simulation, over more than one period
+	for minute := 0; minute <= 2*basin.period; minute++ {
+		for y := 0; y < grid.height; y++ {
+			for x := 0; x < grid.width; x++ {
+				position := common.Point{X: x, Y: y}
+				_, found := grid.blizzards[position]
+				assert.Equal(t, found, basin.HasBlizzard(position, minute), "minute %d at %v", minute, position)
+			}
+		}
+		grid = grid.Iterate()
+	}
+}
+
+// moves of the puzzle's worked example
+var examplePositions = []common.Point{
+	{X: 1, Y: 0}, {X: 1, Y: 1}, {X: 1, Y: 2}, {X: 1, Y: 2}, {X: 1, Y: 1},
+	{X: 2, Y: 1}, {X: 3, Y: 1}, {X: 3, Y: 2}, {X: 2, Y: 2}, {X: 2, Y: 1},
+	{X: 3, Y: 1}, {X: 3, Y: 1}, {X: 3, Y: 2}, {X: 3, Y: 3}, {X: 4, Y: 3},
+	{X: 5, Y: 3}, {X: 6, Y: 3}, {X: 6, Y: 4}, {X: 6, Y: 5},
+}
+
+func TestReplay(t *testing.T) {
+	grid := NewGrid(data)
+	trip := NewTrip(0, examplePositions)
+	assert.Nil(t, NewBasin(grid).CheckTrip(trip, TripOptions{}))
+	assert.NotNil(t, NewBasin(grid).CheckTrip(trip, TripOptions{NoWaiting: true}))
+	assert.Equal(t, 18, trip.Arrival())
+
+	replay := strings.Split(trip.Replay(grid), "\n")
+	assert.Equal(t, []string{
+		"Initial state:",
+		"#E######",
+		"#>>.<^<#",
+		"#.<..<<#",
+		"#>v.><>#",
+		"#<^v^^>#",
+		"######.#",
+		"",
+		"Minute 1, move down:",
+		"#.######",
+		"#E>3.<.#",
+	}, replay[:11])
+	assert.Equal(t, []string{
+		"Minute 18, move down:",
+		"#.######",
+		"#>2.<.<#",
+		"#.2v^2<#",
+		"#>..>2>#",
+		"#<....>#",
+		"######E#",
+	}, replay[len(replay)-8:len(replay)-1])
+}
+
+func TestPlanTrip(t *testing.T) {
+	basin := NewBasin(NewGrid(data))
+	start, end := basin.StartPosition(), basin.EndPosition()
+
+	trip, err := basin.PlanTrip([]common.Point{start, end}, 0, TripOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 18, trip.Arrival())
+	assert.Nil(t, basin.CheckTrip(trip, TripOptions{}))
+
+	// through an interior cell
+	middle := common.Point{X: 4, Y: 2}
+	trip, err = basin.PlanTrip([]common.Point{start, middle, end}, 0, TripOptions{})
+	assert.Nil(t, err)
+	assert.Contains(t, trip.Positions(), middle)
+	assert.Nil(t, basin.CheckTrip(trip, TripOptions{}))
+	assert.GreaterOrEqual(t, trip.Arrival(), 18)
+
+	// the example basin cannot be crossed without ever waiting
+	_, err = basin.PlanTrip([]common.Point{start, end}, 0, TripOptions{NoWaiting: true})
+	assert.EqualError(t, err, "no path from common.Point{X:1, Y:0} to common.Point{X:6, Y:5}")
+
+	// the first waypoint has a blizzard on it at minute 0
+	_, err = basin.PlanTrip([]common.Point{{X: 1, Y: 1}, end}, 0, TripOptions{})
+	assert.EqualError(t, err, "minute 0: common.Point{X:1, Y:1} is not free")
+	_, err = basin.PlanTrip([]common.Point{{X: 1, Y: 1}, end}, 1, TripOptions{})
+	assert.Nil(t, err)
+
+	_, err = basin.PlanTrip([]common.Point{start, {X: 0, Y: 1}}, 0, TripOptions{})
+	assert.NotNil(t, err)
+}
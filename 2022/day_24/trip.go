@@ -0,0 +1,208 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"strings"
+
+	"github.com/pducolin/advent-of-code/2022/common"
+)
+
+type TripOptions struct {
+	// the expedition has to move every minute
+	NoWaiting bool
+}
+
+// Move is what the expedition does during a minute, and where it ends
+type Move struct {
+	Minute   int
+	Action   string
+	Position common.Point
+}
+
+// Trip is a minute-by-minute route of the expedition
+type Trip struct {
+	Start int
+	// position at the start minute
+	From  common.Point
+	Moves []Move
+}
+
+// build a trip from the position of the expedition at every minute, starting
+// from the start minute
+func NewTrip(start int, positions []common.Point) Trip {
+	trip := Trip{
+		Start: start,
+		From:  positions[0],
+		Moves: []Move{},
+	}
+	for i := 1; i < len(positions); i++ {
+		trip.Moves = append(trip.Moves, Move{
+			Minute:   start + i,
+			Action:   action(positions[i-1], positions[i]),
+			Position: positions[i],
+		})
+	}
+	return trip
+}
+
+func action(from, to common.Point) string {
+	switch {
+	case to.Y < from.Y:
+		return "move up"
+	case to.Y > from.Y:
+		return "move down"
+	case to.X < from.X:
+		return "move left"
+	case to.X > from.X:
+		return "move right"
+	}
+	return "wait"
+}
+
+// minute the expedition reaches its last position
+func (trip Trip) Arrival() int {
+	return trip.Start + len(trip.Moves)
+}
+
+// position of the expedition at every minute, from the start minute
+func (trip Trip) Positions() []common.Point {
+	positions := []common.Point{trip.From}
+	for _, move := range trip.Moves {
+		positions = append(positions, move.Position)
+	}
+	return positions
+}
+
+// PlanTrip finds the fastest trip through all waypoints in order, leaving the
+// first one at the given minute
+func (basin *Basin) PlanTrip(waypoints []common.Point, startMinute int, options TripOptions) (Trip, error) {
+	if len(waypoints) == 0 {
+		return Trip{}, fmt.Errorf("no waypoint")
+	}
+	for _, waypoint := range waypoints {
+		if basin.IsWall(waypoint) {
+			return Trip{}, fmt.Errorf("waypoint %#v is a wall", waypoint)
+		}
+	}
+	if !basin.IsFree(waypoints[0], startMinute) {
+		return Trip{}, fmt.Errorf("minute %d: %#v is not free", startMinute, waypoints[0])
+	}
+
+	positions := []common.Point{waypoints[0]}
+	minute := startMinute
+	for i := 1; i < len(waypoints); i++ {
+		leg, err := basin.findPath(waypoints[i-1], waypoints[i], minute, options)
+		if err != nil {
+			return Trip{}, err
+		}
+		positions = append(positions, leg[1:]...)
+		minute += len(leg) - 1
+	}
+	return NewTrip(startMinute, positions), nil
+}
+
+// CheckTrip tells why a trip is not possible, if it is not
+func (basin *Basin) CheckTrip(trip Trip, options TripOptions) error {
+	previous := trip.From
+	for _, move := range trip.Moves {
+		if manhattan(previous, move.Position) > 1 {
+			return fmt.Errorf("minute %d: %#v is not next to %#v", move.Minute, move.Position, previous)
+		}
+		if options.NoWaiting && move.Position == previous {
+			return fmt.Errorf("minute %d: waiting at %#v", move.Minute, previous)
+		}
+		if !basin.IsFree(move.Position, move.Minute) {
+			return fmt.Errorf("minute %d: %#v is not free", move.Minute, move.Position)
+		}
+		previous = move.Position
+	}
+	return nil
+}
+
+// Replay draws the trip like the puzzle does, the expedition as E over the
+// blizzards of the grid at minute 0
+func (trip Trip) Replay(grid Grid) string {
+	for grid.timeElapsed < trip.Start {
+		grid = grid.Iterate()
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Initial state:\n")
+	writeExpedition(&sb, grid, trip.From)
+	for _, move := range trip.Moves {
+		grid = grid.Iterate()
+		sb.WriteString(fmt.Sprintf("\nMinute %d, %s:\n", move.Minute, move.Action))
+		writeExpedition(&sb, grid, move.Position)
+	}
+	return sb.String()
+}
+
+func writeExpedition(sb *strings.Builder, grid Grid, expedition common.Point) {
+	for y, line := range grid.ToString() {
+		if y == expedition.Y {
+			line = line[:expedition.X] + "E" + line[expedition.X+1:]
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+}
+
+// A* from the starting point, left at the given minute, to the target;
+// returns the position at every minute
+func (basin *Basin) findPath(startingPoint, targetPoint common.Point, startMinute int, options TripOptions) ([]common.Point, error) {
+	// position each visited state was first reached from; states are only
+	// expanded once, so this is bounded by positions times the period
+	from := map[basinState]common.Point{}
+
+	queue := searchHeap{{
+		position: startingPoint,
+		minute:   startMinute,
+		estimate: startMinute + manhattan(startingPoint, targetPoint),
+		from:     startingPoint,
+	}}
+	heap.Init(&queue)
+
+	for queue.Len() > 0 {
+		current := heap.Pop(&queue).(searchNode)
+
+		state := basinState{position: current.position, phase: current.minute % basin.period}
+		if _, found := from[state]; found {
+			continue
+		}
+		from[state] = current.from
+
+		if current.position == targetPoint {
+			return basin.walkBack(from, current.position, current.minute, startMinute), nil
+		}
+
+		nextMinute := current.minute + 1
+		for _, move := range basin.GetMoves(current.position, nextMinute) {
+			if options.NoWaiting && move == current.position {
+				continue
+			}
+			if _, found := from[basinState{position: move, phase: nextMinute % basin.period}]; found {
+				continue
+			}
+			heap.Push(&queue, searchNode{
+				position: move,
+				minute:   nextMinute,
+				estimate: nextMinute + manhattan(move, targetPoint),
+				from:     current.position,
+			})
+		}
+	}
+
+	return nil, fmt.Errorf("no path from %#v to %#v", startingPoint, targetPoint)
+}
+
+// positions from the start minute to the given one, following the states
+// each position was reached from
+func (basin *Basin) walkBack(from map[basinState]common.Point, position common.Point, minute int, startMinute int) []common.Point {
+	path := make([]common.Point, minute-startMinute+1)
+	for ; minute >= startMinute; minute-- {
+		path[minute-startMinute] = position
+		position = from[basinState{position: position, phase: minute % basin.period}]
+	}
+	return path
+}
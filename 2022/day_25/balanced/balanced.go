@@ -0,0 +1,273 @@
+package balanced
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// System is a balanced numeral system: an odd base whose digits go from
+// -(base-1)/2 to (base-1)/2, written with one rune each
+type System struct {
+	base int
+	half int
+	// runes of the digits, from the lowest value to the highest
+	alphabet []rune
+	values   map[rune]int
+}
+
+// NewSystem builds a system from its digit runes sorted by value, lowest
+// first: base 5 SNAFU is "=-012", balanced ternary could be "-0+"
+func NewSystem(alphabet string) (*System, error) {
+	runes := []rune(alphabet)
+	if len(runes) < 3 || len(runes)%2 == 0 {
+		return nil, fmt.Errorf("a balanced base needs an odd number of digits, at least 3, got %q", alphabet)
+	}
+	system := &System{
+		base:     len(runes),
+		half:     len(runes) / 2,
+		alphabet: runes,
+		values:   map[rune]int{},
+	}
+	for i, r := range runes {
+		if _, found := system.values[r]; found {
+			return nil, fmt.Errorf("digit %q appears twice in %q", r, alphabet)
+		}
+		system.values[r] = i - system.half
+	}
+	return system, nil
+}
+
+func MustSystem(alphabet string) *System {
+	system, err := NewSystem(alphabet)
+	if err != nil {
+		panic(err)
+	}
+	return system
+}
+
+// SNAFU is the base 5 system of the elves' fuel requirements
+var SNAFU = MustSystem("=-012")
+
+func (system *System) Base() int {
+	return system.base
+}
+
+// Number is a value written in a balanced system; the zero Number is 0,
+// without a system until combined with another number
+type Number struct {
+	system *System
+	// digit values, least significant first, without leading zeros
+	digits []int
+}
+
+func (system *System) Zero() Number {
+	return Number{system: system, digits: []int{}}
+}
+
+// Parse reads a number written most significant digit first
+func (system *System) Parse(s string) (Number, error) {
+	if s == "" {
+		return Number{}, errors.New("empty number")
+	}
+	runes := []rune(s)
+	digits := make([]int, len(runes))
+	for i, r := range runes {
+		value, found := system.values[r]
+		if !found {
+			return Number{}, fmt.Errorf("invalid digit %q at position %d of %q", r, i, s)
+		}
+		digits[len(runes)-1-i] = value
+	}
+	return system.number(digits), nil
+}
+
+func (system *System) MustParse(s string) Number {
+	number, err := system.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return number
+}
+
+// digit and carry such that value = digit + carry * base, with the digit
+// within the balanced range
+func (system *System) split(value int) (digit, carry int) {
+	carry = floorDiv(value+system.half, system.base)
+	return value - carry*system.base, carry
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// number from digit values that may be out of range, carrying over
+func (system *System) number(values []int) Number {
+	digits := []int{}
+	carry := 0
+	for i := 0; i < len(values) || carry != 0; i++ {
+		value := carry
+		if i < len(values) {
+			value += values[i]
+		}
+		var digit int
+		digit, carry = system.split(value)
+		digits = append(digits, digit)
+	}
+	for len(digits) > 0 && digits[len(digits)-1] == 0 {
+		digits = digits[:len(digits)-1]
+	}
+	return Number{system: system, digits: digits}
+}
+
+func (system *System) FromInt64(n int64) Number {
+	base, half := int64(system.base), int64(system.half)
+	digits := []int{}
+	for n != 0 {
+		q, r := n/base, n%base
+		if r > half {
+			r -= base
+			q++
+		} else if r < -half {
+			r += base
+			q--
+		}
+		digits = append(digits, int(r))
+		n = q
+	}
+	return Number{system: system, digits: digits}
+}
+
+func (system *System) FromBig(n *big.Int) Number {
+	base, half := big.NewInt(int64(system.base)), big.NewInt(int64(system.half))
+	digits := []int{}
+	q, r := new(big.Int).Set(n), new(big.Int)
+	for q.Sign() != 0 {
+		q.QuoRem(q, base, r)
+		if r.Cmp(half) > 0 {
+			r.Sub(r, base)
+			q.Add(q, big.NewInt(1))
+		} else if r.Cmp(new(big.Int).Neg(half)) < 0 {
+			r.Add(r, base)
+			q.Sub(q, big.NewInt(1))
+		}
+		digits = append(digits, int(r.Int64()))
+	}
+	return Number{system: system, digits: digits}
+}
+
+func (number Number) Big() *big.Int {
+	ret := new(big.Int)
+	if len(number.digits) == 0 {
+		return ret
+	}
+	base := big.NewInt(int64(number.system.base))
+	for i := len(number.digits) - 1; i >= 0; i-- {
+		ret.Mul(ret, base)
+		ret.Add(ret, big.NewInt(int64(number.digits[i])))
+	}
+	return ret
+}
+
+// Int64 fails when the number does not fit in 64 bits
+func (number Number) Int64() (int64, error) {
+	ret := number.Big()
+	if !ret.IsInt64() {
+		return 0, fmt.Errorf("%s overflows int64", number)
+	}
+	return ret.Int64(), nil
+}
+
+// String writes the number most significant digit first
+func (number Number) String() string {
+	if len(number.digits) == 0 {
+		if number.system == nil {
+			return "0"
+		}
+		return string(number.system.alphabet[number.system.half])
+	}
+	var sb strings.Builder
+	for i := len(number.digits) - 1; i >= 0; i-- {
+		sb.WriteRune(number.system.alphabet[number.digits[i]+number.system.half])
+	}
+	return sb.String()
+}
+
+// -1, 0 or 1, the sign of the most significant digit
+func (number Number) Sign() int {
+	if len(number.digits) == 0 {
+		return 0
+	}
+	if number.digits[len(number.digits)-1] < 0 {
+		return -1
+	}
+	return 1
+}
+
+func (number Number) System() *System {
+	return number.system
+}
+
+// both numbers in the same system, a zero Number taking the system of the
+// other one
+func (number Number) sameSystem(other Number) (Number, Number) {
+	if number.system == nil {
+		number.system = other.system
+	}
+	if other.system == nil {
+		other.system = number.system
+	}
+	if number.system != other.system {
+		panic(fmt.Errorf("%s and %s are written in different systems", number, other))
+	}
+	return number, other
+}
+
+// Neg flips every digit, no carry needed
+func (number Number) Neg() Number {
+	digits := make([]int, len(number.digits))
+	for i, digit := range number.digits {
+		digits[i] = -digit
+	}
+	return Number{system: number.system, digits: digits}
+}
+
+func (number Number) Add(other Number) Number {
+	number, other = number.sameSystem(other)
+	size := len(number.digits)
+	if len(other.digits) > size {
+		size = len(other.digits)
+	}
+	values := make([]int, size)
+	for i, digit := range number.digits {
+		values[i] += digit
+	}
+	for i, digit := range other.digits {
+		values[i] += digit
+	}
+	return number.system.number(values)
+}
+
+func (number Number) Sub(other Number) Number {
+	return number.Add(other.Neg())
+}
+
+// Mul multiplies digit by digit, like on paper, carrying at the end
+func (number Number) Mul(other Number) Number {
+	number, other = number.sameSystem(other)
+	if len(number.digits) == 0 || len(other.digits) == 0 {
+		return number.system.Zero()
+	}
+	values := make([]int, len(number.digits)+len(other.digits))
+	for i, a := range number.digits {
+		for j, b := range other.digits {
+			values[i+j] += a * b
+		}
+	}
+	return number.system.number(values)
+}
@@ -0,0 +1,78 @@
+package balanced
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArithmetic(t *testing.T) {
+	values := []int64{0, 1, -1, 2, 3, -7, 2022, -12345, 314159265}
+	for _, a := range values {
+		for _, b := range values {
+			x, y := SNAFU.FromInt64(a), SNAFU.FromInt64(b)
+			sum, _ := x.Add(y).Int64()
+			assert.Equal(t, a+b, sum, "Failed adding %d and %d", a, b)
+			difference, _ := x.Sub(y).Int64()
+			assert.Equal(t, a-b, difference, "Failed subtracting %d and %d", a, b)
+			product, _ := x.Mul(y).Int64()
+			assert.Equal(t, a*b, product, "Failed multiplying %d and %d", a, b)
+		}
+	}
+	assert.Equal(t, "0", SNAFU.Zero().String())
+	assert.Equal(t, "-2", SNAFU.FromInt64(-3).String())
+	assert.Equal(t, -1, SNAFU.MustParse("-2").Sign())
+}
+
+func TestOtherSystems(t *testing.T) {
+	ternary := MustSystem("-0+")
+	assert.Equal(t, "+--", ternary.FromInt64(5).String())
+	// 2 * 7 = 27 - 9 - 3 - 1
+	assert.Equal(t, "+---", ternary.MustParse("+-").Mul(ternary.MustParse("+-+")).String())
+
+	septenary := MustSystem("cba0123")
+	number, err := septenary.Parse("3c")
+	assert.Nil(t, err)
+	value, _ := number.Int64()
+	assert.Equal(t, int64(18), value)
+
+	_, err = NewSystem("=-01")
+	assert.NotNil(t, err)
+	_, err = NewSystem("=-0-2")
+	assert.NotNil(t, err)
+}
+
+func TestBig(t *testing.T) {
+	huge, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	number := SNAFU.FromBig(huge)
+	assert.Equal(t, huge, number.Big())
+	_, err := number.Int64()
+	assert.NotNil(t, err)
+	square := number.Mul(number)
+	assert.Equal(t, new(big.Int).Mul(huge, huge), square.Big())
+}
+
+func TestInvalidDigit(t *testing.T) {
+	_, err := SNAFU.Parse("1=3")
+	assert.NotNil(t, err)
+	_, err = SNAFU.Parse("")
+	assert.NotNil(t, err)
+}
+
+func TestZeroValue(t *testing.T) {
+	var number Number
+	assert.Equal(t, "0", number.String())
+	assert.Equal(t, 0, number.Sign())
+	value, err := number.Int64()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), value)
+	one := SNAFU.MustParse("1")
+	assert.Equal(t, "1", number.Add(one).String())
+	assert.Equal(t, "1", one.Add(number).String())
+	assert.Equal(t, "-", number.Sub(one).String())
+	assert.Equal(t, "1", one.Sub(number).String())
+	assert.Equal(t, "0", number.Mul(one).String())
+	assert.Equal(t, SNAFU, number.Add(one).System())
+	assert.Equal(t, "0", number.Add(number).String())
+}
@@ -4,9 +4,9 @@ import (
 	_ "embed"
 	"flag"
 	"fmt"
-	"math"
-	"strconv"
 	"strings"
+
+	"github.com/pducolin/advent-of-code/2022/day_25/balanced"
 )
 
 //go:embed input.txt
@@ -23,59 +23,27 @@ func main() {
 }
 
 func part1(data string) string {
-	sum := 0
+	sum := balanced.SNAFU.Zero()
 
 	for _, line := range strings.Split(data, "\n") {
-		sum += SnafuToInt(line)
+		number, err := balanced.SNAFU.Parse(line)
+		if err != nil {
+			panic(err)
+		}
+		sum = sum.Add(number)
 	}
 
-	return IntToSnafu(sum)
+	return sum.String()
 }
 
 func IntToSnafu(number int) string {
-	snafuChars := []string{}
-	for number > 0 {
-		mod := number % 5
-		if mod <= 2 {
-			snafuChars = append(snafuChars, strconv.Itoa(mod))
-			number -= mod
-		} else if mod == 3 {
-			snafuChars = append(snafuChars, "=")
-			number += 5 - mod
-		} else {
-			snafuChars = append(snafuChars, "-")
-			number += 5 - mod
-		}
-		number /= 5
-	}
-	return invertString(strings.Join(snafuChars, ""))
+	return balanced.SNAFU.FromInt64(int64(number)).String()
 }
 
 func SnafuToInt(snafu string) int {
-	num := 0
-
-	for i, r := range invertString(snafu) {
-		switch r {
-		case '0':
-			num += int(r-'0') * int(math.Pow(5, float64(i)))
-		case '1':
-			num += int(r-'0') * int(math.Pow(5, float64(i)))
-		case '2':
-			num += int(r-'0') * int(math.Pow(5, float64(i)))
-		case '-':
-			num -= int(math.Pow(5, float64(i)))
-		case '=':
-			num -= 2 * int(math.Pow(5, float64(i)))
-		}
-	}
-
-	return num
-}
-
-func invertString(s string) string {
-	ret := ""
-	for i := len(s) - 1; i >= 0; i-- {
-		ret += string(s[i])
+	number, err := balanced.SNAFU.MustParse(snafu).Int64()
+	if err != nil {
+		panic(err)
 	}
-	return ret
+	return int(number)
 }